@@ -0,0 +1,340 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BatchOptions configures how OTLPExporter, LokiExporter,
+// ElasticsearchExporter and WebhookExporter group entries into requests. The
+// zero value uses the defaults.
+type BatchOptions struct {
+	MaxEntries    int           // entries per request; 512 when 0
+	FlushInterval time.Duration // longest time an entry waits for its batch to fill; 1s when 0
+	// QueueSize bounds the entries waiting to be sent, 4096 when 0. Entries
+	// arriving while the queue is full are dropped and counted in Stats.
+	QueueSize int
+	// MaxRetries is how many times a failed request is retried, 5 when 0
+	// and none when negative, waiting Backoff (200ms when 0) then twice as
+	// long each time, up to 30s.
+	MaxRetries int
+	Backoff    time.Duration
+
+	// minInterval is the shortest time between two batches, other than
+	// those sent by flush; set by WebhookExporter.
+	minInterval time.Duration
+}
+
+const maxBackoff = 30 * time.Second
+
+// maxResponseSize bounds the response bodies read by postBatch.
+const maxResponseSize = 16 << 20
+
+// flushTimeout bounds the flushes done on Close and before a fatal entry
+// exits the program.
+const flushTimeout = 5 * time.Second
+
+// batchItem is a queued entry with its rendering by the sink's Format.
+type batchItem struct {
+	entry *Entry
+	p     []byte
+}
+
+// batcher queues entries and hands them to send in batches from a single
+// goroutine, retrying failed batches with backoff.
+type batcher struct {
+	opts    BatchOptions
+	name    string // for error messages
+	send    func(ctx context.Context, batch []batchItem) error
+	queue   chan batchItem
+	flushes chan chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	// mu guards closed, so that no entry is queued once close has begun.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newBatcher(name string, opts BatchOptions, send func(ctx context.Context, batch []batchItem) error) *batcher {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 512
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 4096
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 5
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 200 * time.Millisecond
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &batcher{
+		opts:    opts,
+		name:    name,
+		send:    send,
+		queue:   make(chan batchItem, opts.QueueSize),
+		flushes: make(chan chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues an entry without blocking, except for fatal entries which wait
+// for room and are sent before returning, since the program is about to exit.
+func (b *batcher) add(entry *Entry, p []byte) error {
+	item := batchItem{entry: entry, p: p}
+	if entry.Level > FatalLevel {
+		return b.enqueue(item, nil)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := b.enqueue(item, ctx.Done()); err != nil {
+		return err
+	}
+	return b.flush(ctx)
+}
+
+// enqueue queues item, waiting for room until wait is closed, or not at all
+// when wait is nil. Entries left out for want of room are counted, not reported.
+func (b *batcher) enqueue(item batchItem, wait <-chan struct{}) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return fmt.Errorf("%s closed", b.name)
+	}
+	if wait == nil {
+		select {
+		case b.queue <- item:
+		default:
+			stats.dropped.Add(1)
+		}
+		return nil
+	}
+	select {
+	case b.queue <- item:
+		return nil
+	case <-wait:
+		stats.dropped.Add(1)
+		return fmt.Errorf("%s queue full", b.name)
+	}
+}
+
+// flush returns once the entries queued so far have been sent or given up on.
+func (b *batcher) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case b.flushes <- done:
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close sends the queued entries, giving up on them when ctx is done, and stops.
+func (b *batcher) close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	err := b.flush(ctx)
+	b.cancel()
+	<-b.stopped
+	return err
+}
+
+func (b *batcher) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]batchItem, 0, b.opts.MaxEntries)
+	// with a minInterval, batches that are due before next wait for delayed
+	var next time.Time
+	var delayed <-chan time.Time
+	export := func() {
+		if len(batch) > 0 {
+			batch = b.export(batch)
+			if b.opts.minInterval > 0 {
+				next = time.Now().Add(b.opts.minInterval)
+			}
+		}
+	}
+	exportWhenDue := func() {
+		if wait := time.Until(next); wait > 0 {
+			if delayed == nil {
+				delayed = time.After(wait)
+			}
+			return
+		}
+		export()
+	}
+	for {
+		queue := b.queue
+		if len(batch) >= b.opts.MaxEntries {
+			queue = nil // full until delayed
+		}
+		select {
+		case <-b.ctx.Done():
+			return
+		case item := <-queue:
+			batch = append(batch, item)
+			if len(batch) == b.opts.MaxEntries {
+				exportWhenDue()
+			}
+		case <-ticker.C:
+			exportWhenDue()
+		case <-delayed:
+			delayed = nil
+			export()
+		case done := <-b.flushes:
+			// take everything queued before the flush
+			for n := len(b.queue); n > 0; n-- {
+				// the batch may be full already, held back by minInterval
+				if len(batch) >= b.opts.MaxEntries {
+					export()
+				}
+				batch = append(batch, <-b.queue)
+			}
+			export()
+			close(done)
+		}
+	}
+}
+
+// export sends batch, retrying when that may help, and returns it emptied.
+func (b *batcher) export(batch []batchItem) []batchItem {
+	if len(batch) == 0 {
+		return batch
+	}
+	backoff := b.opts.Backoff
+	pending := batch
+	for attempt := 0; ; attempt++ {
+		err := b.send(b.ctx, pending)
+		if err == nil {
+			break
+		}
+		var partial *partialError
+		if errors.As(err, &partial) {
+			pending = partial.retry
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= b.opts.MaxRetries || b.ctx.Err() != nil {
+			stats.dropped.Add(uint64(len(pending)))
+			fmt.Fprintf(os.Stderr, "Failed to export %d entries to %s, %v\n", len(pending), b.name, err)
+			break
+		}
+		wait := backoff
+		var retry *retryAfterError
+		if errors.As(err, &retry) && retry.after > wait {
+			wait = retry.after
+			if wait > maxBackoff {
+				wait = maxBackoff
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-b.ctx.Done():
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	for i := range batch {
+		batch[i] = batchItem{}
+	}
+	return batch[:0]
+}
+
+// permanentError is a failure that retrying would not fix, such as a rejected request.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// partialError is a failure of some entries of a batch, the only ones to retry.
+type partialError struct {
+	err   error
+	retry []batchItem
+}
+
+func (e *partialError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialError) Unwrap() error {
+	return e.err
+}
+
+// retryAfterError is a failure the server asked to retry after some time.
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// postBatch POSTs body to url and returns the response body. Failures are
+// classified: 429 and 502 to 504 are worth retrying, honouring Retry-After,
+// other statuses are not.
+func postBatch(ctx context.Context, client *http.Client, url, contentType string, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &permanentError{err: err}
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(message))
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+			return nil, &retryAfterError{err: err, after: time.Duration(seconds) * time.Second}
+		}
+		return nil, err
+	}
+	return nil, &permanentError{err: err}
+}
@@ -0,0 +1,218 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder is a send function keeping the messages of each batch.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]string
+	errs    []error // returned by the first calls
+}
+
+func (r *batchRecorder) send(_ context.Context, batch []batchItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return err
+	}
+	messages := make([]string, len(batch))
+	for i, item := range batch {
+		messages[i] = item.entry.Message
+	}
+	r.batches = append(r.batches, messages)
+	return nil
+}
+
+func (r *batchRecorder) get() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func TestBatcher_maxEntries(t *testing.T) {
+	r := &batchRecorder{}
+	b := newBatcher("test", BatchOptions{MaxEntries: 2, FlushInterval: time.Hour}, r.send)
+	for _, msg := range []string{"a", "b", "c"} {
+		require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: msg}, nil))
+	}
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, 5*time.Second, time.Millisecond)
+	require.NoError(t, b.close(context.Background()))
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, r.get())
+	assert.EqualError(t, b.add(&Entry{Level: InfoLevel, Message: "d"}, nil), "test closed")
+}
+
+func TestBatcher_minIntervalFlush(t *testing.T) {
+	r := &batchRecorder{}
+	b := newBatcher("test", BatchOptions{MaxEntries: 2, FlushInterval: time.Hour, minInterval: time.Hour}, r.send)
+	for _, msg := range []string{"a", "b"} {
+		require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: msg}, nil))
+	}
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, 5*time.Second, time.Millisecond)
+	// full batches wait for minInterval, and are kept to MaxEntries when flushed
+	for _, msg := range []string{"c", "d", "e", "f", "g"} {
+		require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: msg}, nil))
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, r.get(), 1)
+	require.NoError(t, b.close(context.Background()))
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}, {"g"}}, r.get())
+}
+
+func TestBatcher_flushInterval(t *testing.T) {
+	r := &batchRecorder{}
+	b := newBatcher("test", BatchOptions{FlushInterval: 10 * time.Millisecond}, r.send)
+	defer b.close(context.Background())
+	require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: "a"}, nil))
+	require.Eventually(t, func() bool { return len(r.get()) == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, [][]string{{"a"}}, r.get())
+}
+
+func TestBatcher_fatal(t *testing.T) {
+	r := &batchRecorder{}
+	b := newBatcher("test", BatchOptions{FlushInterval: time.Hour}, r.send)
+	defer b.close(context.Background())
+	require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: "a"}, nil))
+	require.NoError(t, b.add(&Entry{Level: FatalLevel, Message: "b"}, nil))
+	// sent before add returned
+	assert.Equal(t, [][]string{{"a", "b"}}, r.get())
+}
+
+func TestBatcher_retry(t *testing.T) {
+	ResetStats()
+	r := &batchRecorder{errs: []error{errors.New("unavailable"), errors.New("unavailable")}}
+	b := newBatcher("test", BatchOptions{Backoff: time.Millisecond}, r.send)
+	require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: "a"}, nil))
+	require.NoError(t, b.close(context.Background()))
+	assert.Equal(t, [][]string{{"a"}}, r.get())
+	assert.Equal(t, uint64(0), Stats().Dropped)
+
+	// given up on after MaxRetries, or right away when permanent
+	r = &batchRecorder{errs: []error{errors.New("unavailable"), errors.New("unavailable")}}
+	b = newBatcher("test", BatchOptions{Backoff: time.Millisecond, MaxRetries: 1}, r.send)
+	require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: "a"}, nil))
+	require.NoError(t, b.close(context.Background()))
+	r = &batchRecorder{errs: []error{&permanentError{err: errors.New("bad request")}}}
+	b = newBatcher("test", BatchOptions{Backoff: time.Millisecond}, r.send)
+	require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: "b"}, nil))
+	require.NoError(t, b.close(context.Background()))
+	assert.Empty(t, r.get())
+	assert.Equal(t, uint64(2), Stats().Dropped)
+}
+
+func TestBatcher_queueFull(t *testing.T) {
+	ResetStats()
+	release := make(chan struct{})
+	b := newBatcher("test", BatchOptions{MaxEntries: 1, QueueSize: 2}, func(context.Context, []batchItem) error {
+		<-release
+		return nil
+	})
+	for i := 0; i < 10; i++ {
+		require.NoError(t, b.add(&Entry{Level: InfoLevel}, nil))
+	}
+	close(release)
+	require.NoError(t, b.close(context.Background()))
+	// one being sent, two queued
+	assert.GreaterOrEqual(t, Stats().Dropped, uint64(7))
+}
+
+func TestBatcher_fatalQueueFull(t *testing.T) {
+	ResetStats()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var sent []string
+	b := newBatcher("test", BatchOptions{MaxEntries: 1, QueueSize: 1}, func(_ context.Context, batch []batchItem) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, batch[0].entry.Message)
+		return nil
+	})
+	defer b.close(context.Background())
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.add(&Entry{Level: InfoLevel, Message: "info"}, nil))
+	}
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	// waits for room rather than being dropped, then for the flush
+	require.NoError(t, b.add(&Entry{Level: FatalLevel, Message: "fatal"}, nil))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "fatal", sent[len(sent)-1])
+}
+
+func TestBatcher_addWhileClosing(t *testing.T) {
+	var sent atomic.Int64
+	b := newBatcher("test", BatchOptions{FlushInterval: time.Hour}, func(_ context.Context, batch []batchItem) error {
+		sent.Add(int64(len(batch)))
+		return nil
+	})
+	var refused atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if b.add(&Entry{Level: InfoLevel}, nil) != nil {
+					refused.Add(1)
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	require.NoError(t, b.close(context.Background()))
+	wg.Wait()
+	// every entry is either sent or refused, none is queued after close
+	assert.Equal(t, int64(800), sent.Load()+refused.Load())
+}
+
+func TestPostBatch(t *testing.T) {
+	status := http.StatusOK
+	retryAfter := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/plain", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("details\n"))
+	}))
+	defer srv.Close()
+	post := func() error {
+		_, err := postBatch(context.Background(), srv.Client(), srv.URL, "text/plain", map[string]string{"Authorization": "secret"}, []byte("body"))
+		return err
+	}
+
+	body, err := postBatch(context.Background(), srv.Client(), srv.URL, "text/plain", map[string]string{"Authorization": "secret"}, []byte("body"))
+	assert.NoError(t, err)
+	assert.Equal(t, "details\n", string(body))
+
+	status = http.StatusServiceUnavailable
+	err = post()
+	assert.EqualError(t, err, "503 Service Unavailable: details")
+	var permanent *permanentError
+	assert.False(t, errors.As(err, &permanent))
+
+	retryAfter = "3"
+	status = http.StatusTooManyRequests
+	var retry *retryAfterError
+	require.True(t, errors.As(post(), &retry))
+	assert.Equal(t, 3*time.Second, retry.after)
+
+	status = http.StatusBadRequest
+	assert.True(t, errors.As(post(), &permanent))
+}
@@ -0,0 +1,311 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the whole configuration of package logger, as loaded by LoadConfig
+// and applied by ApplyConfig. Start from DefaultConfig: the zero Level is PanicLevel.
+type Config struct {
+	Level          Level            `yaml:"level" json:"level"`
+	Format         Format           `yaml:"format" json:"format"`
+	Output         string           `yaml:"output" json:"output"` // stdout, stderr (the default) or a file to append to
+	CallerFullpath bool             `yaml:"callerFullpath" json:"callerFullpath"`
+	Levels         map[string]Level `yaml:"levels" json:"levels"` // named logger levels, as set by SetLevelFor
+	Sinks          []SinkConfig     `yaml:"sinks" json:"sinks"`
+	Sampling       SamplingConfig   `yaml:"sampling" json:"sampling"`
+	Redaction      RedactionConfig  `yaml:"redaction" json:"redaction"`
+}
+
+// SinkConfig describes a Sink writing to Output: stdout, stderr or a file.
+type SinkConfig struct {
+	Output string `yaml:"output" json:"output"`
+	Level  Level  `yaml:"level" json:"level"` // InfoLevel when left out of a config file
+	Format Format `yaml:"format" json:"format"`
+}
+
+// SamplingConfig limits repeated entries: in each Tick, the first Initial
+// entries with the same level and message (the format of Infof and the like)
+// are written, then every Thereafter-th. Fatal entries are never sampled.
+// An Initial of 0 turns sampling off.
+type SamplingConfig struct {
+	Initial    int           `yaml:"initial" json:"initial"`
+	Thereafter int           `yaml:"thereafter" json:"thereafter"`
+	Tick       time.Duration `yaml:"tick" json:"tick"` // 1s when 0
+}
+
+// RedactionConfig hides the values of the listed fields, and the text matching
+// the regular expressions in Patterns in messages and string fields.
+type RedactionConfig struct {
+	Fields   []string `yaml:"fields" json:"fields"`
+	Patterns []string `yaml:"patterns" json:"patterns"`
+}
+
+func DefaultConfig() Config {
+	return Config{Level: InfoLevel, Format: TextFormat}
+}
+
+func (s *SinkConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain SinkConfig
+	p := plain{Level: InfoLevel}
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*s = SinkConfig(p)
+	return nil
+}
+
+// LoadConfig reads a YAML or JSON config file over DefaultConfig.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig parses a YAML or JSON config over DefaultConfig. Unknown keys are errors.
+func ParseConfig(data []byte) (Config, error) {
+	cfg := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// applied holds what the last ApplyConfig set up, to be undone by the next one.
+var applied struct {
+	mu    sync.Mutex
+	sinks []*sinkState
+	files []*os.File
+}
+
+// ApplyConfig checks the whole config, then applies it in one go: if anything
+// is invalid, it reports all the problems and changes nothing. The sinks and
+// named logger levels of cfg replace those of the previous ApplyConfig.
+func ApplyConfig(cfg Config) error {
+	var errs []error
+	invalid := func(name string, err error) {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	if cfg.Level > TraceLevel {
+		invalid("level", fmt.Errorf("not a valid Level: %d", cfg.Level))
+	}
+	if _, err := formatterFor(cfg.Format, nil, nil); err != nil {
+		invalid("format", err)
+	}
+	for name, level := range cfg.Levels {
+		if level > TraceLevel {
+			invalid("levels."+name, fmt.Errorf("not a valid Level: %d", level))
+		}
+	}
+	for i, sink := range cfg.Sinks {
+		if _, err := newFormatter(sink.Format, nil); err != nil {
+			invalid(fmt.Sprintf("sinks[%d].format", i), err)
+		}
+		if sink.Output == "" {
+			invalid(fmt.Sprintf("sinks[%d].output", i), errors.New("missing output"))
+		}
+	}
+	var s *sampler
+	if cfg.Sampling.Initial < 0 || cfg.Sampling.Thereafter < 0 || cfg.Sampling.Tick < 0 {
+		invalid("sampling", errors.New("negative values"))
+	} else if cfg.Sampling.Initial > 0 {
+		tick := cfg.Sampling.Tick
+		if tick == 0 {
+			tick = time.Second
+		}
+		s = newSampler(cfg.Sampling.Initial, cfg.Sampling.Thereafter, tick)
+	}
+	var r *redactor
+	if len(cfg.Redaction.Fields) > 0 || len(cfg.Redaction.Patterns) > 0 {
+		var err error
+		if r, err = newRedactor(cfg.Redaction.Fields, cfg.Redaction.Patterns); err != nil {
+			invalid("redaction.patterns", err)
+		}
+	}
+	outputs := append([]string{cfg.Output}, make([]string, len(cfg.Sinks))...)
+	names := append([]string{"output"}, make([]string, len(cfg.Sinks))...)
+	for i, sink := range cfg.Sinks {
+		outputs[i+1] = sink.Output
+		names[i+1] = fmt.Sprintf("sinks[%d].output", i)
+	}
+	for i, output := range outputs {
+		if !isStdOutput(output) {
+			if err := checkLogFile(output); err != nil {
+				invalid(names[i], err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// open the outputs last, so that nothing is created for an invalid config
+	var files []*os.File
+	writers := make([]io.Writer, len(outputs))
+	for i, output := range outputs {
+		switch output {
+		case "", "stderr":
+			writers[i] = os.Stderr
+		case "stdout":
+			writers[i] = os.Stdout
+		default:
+			f, err := openLogFile(output)
+			if err != nil {
+				invalid(names[i], err)
+				continue
+			}
+			files = append(files, f)
+			writers[i] = f
+		}
+	}
+	if len(errs) > 0 {
+		for _, f := range files {
+			f.Close()
+		}
+		return errors.Join(errs...)
+	}
+
+	applied.mu.Lock()
+	defer applied.mu.Unlock()
+	envFile.mu.Lock()
+	defer envFile.mu.Unlock()
+	sinks := make([]*sinkState, len(cfg.Sinks))
+	for i, sink := range cfg.Sinks {
+		sinks[i], _ = newSinkState(Sink{Writer: writers[i+1], MinLevel: sink.Level, Format: sink.Format})
+	}
+	levels := make(map[string]Level, len(cfg.Levels))
+	for name, level := range cfg.Levels {
+		levels[name] = level
+	}
+	// one store, so that no entry is handled with a mix of the old and new config
+	_ = updateOptions(func(o *options) {
+		o.level = cfg.Level
+		o.levelsFor = levels
+		o.sampler = s
+		o.redactor = r
+		o.fullpath = cfg.CallerFullpath
+		o.format = cfg.Format
+		o.output = writers[0]
+		o.errOutput = nil
+		o.splitThreshold = -1
+		kept := make([]*sinkState, 0, len(o.sinks)+len(sinks))
+		for _, other := range o.sinks {
+			if !containsSink(applied.sinks, other) {
+				kept = append(kept, other)
+			}
+		}
+		o.sinks = append(kept, sinks...)
+	})
+	// the old outputs are closed once the entries being written to them are
+	for _, old := range applied.sinks {
+		old.stop()
+	}
+	drainOutputs()
+	for _, f := range applied.files {
+		f.Close()
+	}
+	closeEnvFile()
+	applied.sinks = sinks
+	applied.files = files
+	return nil
+}
+
+func containsSink(sinks []*sinkState, s *sinkState) bool {
+	for _, other := range sinks {
+		if other == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WatchConfig applies the config file at path, then checks it every interval
+// and applies it again when it changes, logging what changed. A config that
+// fails to load or apply is logged and leaves the current one in place, and
+// an empty file is ignored.
+func WatchConfig(path string, interval time.Duration) (stop func(), err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	current, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyConfig(current); err != nil {
+		return nil, err
+	}
+
+	l := Named("logger")
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			latest, err := os.ReadFile(path)
+			// an empty file is most likely being rewritten
+			if err != nil || len(latest) == 0 || bytes.Equal(latest, data) {
+				continue
+			}
+			data = latest
+			cfg, err := ParseConfig(data)
+			if err == nil {
+				err = ApplyConfig(cfg)
+			}
+			if err != nil {
+				l.Errorf("failed to apply config %s: %v", path, err)
+				continue
+			}
+			if diff := diffConfig(current, cfg); diff != "" {
+				l.Infof("applied config %s: %s", path, diff)
+			}
+			current = cfg
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}, nil
+}
+
+func isStdOutput(output string) bool {
+	return output == "" || output == "stderr" || output == "stdout"
+}
+
+// diffConfig describes the fields that differ between a and b.
+func diffConfig(a, b Config) string {
+	var changes []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		x, y := va.Field(i).Interface(), vb.Field(i).Interface()
+		if !reflect.DeepEqual(x, y) {
+			name := va.Type().Field(i).Tag.Get("yaml")
+			changes = append(changes, fmt.Sprintf("%s: %+v -> %+v", name, x, y))
+		}
+	}
+	return strings.Join(changes, ", ")
+}
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetConfig() {
+	_ = ApplyConfig(DefaultConfig())
+}
+
+func TestParseConfig(t *testing.T) {
+	yamlConfig := `
+level: debug
+format: json
+output: stdout
+callerFullpath: true
+levels:
+  db: trace
+  http: warn
+sinks:
+  - output: /var/log/app.log
+    format: json
+  - output: stderr
+    level: error
+sampling:
+  initial: 100
+  thereafter: 10
+  tick: 2s
+redaction:
+  fields: [password]
+  patterns: ['token=\w+']
+`
+	want := Config{
+		Level:          DebugLevel,
+		Format:         JSONFormat,
+		Output:         "stdout",
+		CallerFullpath: true,
+		Levels:         map[string]Level{"db": TraceLevel, "http": WarnLevel},
+		Sinks: []SinkConfig{
+			{Output: "/var/log/app.log", Level: InfoLevel, Format: JSONFormat},
+			{Output: "stderr", Level: ErrorLevel},
+		},
+		Sampling:  SamplingConfig{Initial: 100, Thereafter: 10, Tick: 2 * time.Second},
+		Redaction: RedactionConfig{Fields: []string{"password"}, Patterns: []string{`token=\w+`}},
+	}
+	got, err := ParseConfig([]byte(yamlConfig))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	jsonConfig := `{"level": "warn", "levels": {"db": 5}, "sinks": [{"output": "stdout"}]}`
+	got, err = ParseConfig([]byte(jsonConfig))
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Level:  WarnLevel,
+		Format: TextFormat,
+		Levels: map[string]Level{"db": DebugLevel},
+		Sinks:  []SinkConfig{{Output: "stdout", Level: InfoLevel}},
+	}, got)
+
+	got, err = ParseConfig(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig(), got)
+}
+
+func TestParseConfig_invalid(t *testing.T) {
+	testCases := []struct {
+		data      string
+		wantError string
+	}{
+		{`level: loud`, `not a valid Level: "loud"`},
+		{`format: xml`, `unknown format: "xml"`},
+		{`lvl: debug`, `field lvl not found in type logger.Config`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tc.data))
+			assert.ErrorContains(t, err, tc.wantError)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("level: error\n"), 0o644))
+	got, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, ErrorLevel, got.Level)
+
+	_, err = LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "app.log")
+	sinkOutput := filepath.Join(dir, "debug.log")
+	defer resetConfig()
+
+	cfg := DefaultConfig()
+	cfg.Output = output
+	cfg.Levels = map[string]Level{"db": DebugLevel}
+	cfg.Sinks = []SinkConfig{{Output: sinkOutput, Level: DebugLevel, Format: JSONFormat}}
+	cfg.Redaction = RedactionConfig{Fields: []string{"password"}}
+	require.NoError(t, ApplyConfig(cfg))
+
+	Debugf("debug")
+	Named("db").Debugf("query")
+	Named("db").WithFields(Fields{"password": "secret"}).Infof("login")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `level=debug msg=query file="config_test.go:[0-9]+" logger=db$`, lines[0])
+	assert.Regexp(t, `level=info msg=login file="config_test.go:[0-9]+" logger=db password="\[REDACTED\]"$`, lines[1])
+
+	require.NoError(t, FlushSinks(context.Background()))
+	data, err = os.ReadFile(sinkOutput)
+	require.NoError(t, err)
+	assert.Equal(t, 3, bytes.Count(data, []byte("\n")))
+	assert.NotContains(t, string(data), "secret")
+
+	// a new config replaces the sinks and named levels
+	require.NoError(t, ApplyConfig(DefaultConfig()))
+	assert.Equal(t, InfoLevel, Named("db").GetLevel())
+	assert.Equal(t, int32(-1), loadOptions().tapLevel)
+	assert.Nil(t, loadOptions().redactor)
+}
+
+func TestApplyConfig_invalid(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{
+		Level:     Level(9),
+		Format:    "xml",
+		Levels:    map[string]Level{"db": Level(8)},
+		Sinks:     []SinkConfig{{Format: "yaml"}},
+		Sampling:  SamplingConfig{Initial: -1},
+		Redaction: RedactionConfig{Patterns: []string{"("}},
+	}
+	assert.EqualError(t, ApplyConfig(cfg), `level: not a valid Level: 9
+format: unknown format: "xml"
+levels.db: not a valid Level: 8
+sinks[0].format: unknown format: "yaml"
+sinks[0].output: missing output
+sampling: negative values
+redaction.patterns: error parsing regexp: missing closing ): `+"`(`")
+
+	cfg = DefaultConfig()
+	cfg.Output = filepath.Join(dir, "app.log")
+	cfg.Sinks = []SinkConfig{{Output: dir}}
+	assert.EqualError(t, ApplyConfig(cfg), "sinks[0].output: "+dir+" is a directory")
+
+	// nothing was applied or left behind
+	assert.Equal(t, InfoLevel, GetLevel())
+	assert.Equal(t, os.Stderr, loadOptions().output)
+	assert.NoFileExists(t, cfg.Output)
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logging.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("level: warn\n"), 0o644))
+	buf := &safeBuffer{}
+	remove, err := AddSink(Sink{Writer: buf, MinLevel: InfoLevel})
+	require.NoError(t, err)
+	defer remove()
+	defer resetConfig()
+
+	stop, err := WatchConfig(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer stop()
+	assert.Equal(t, WarnLevel, GetLevel())
+
+	require.NoError(t, os.WriteFile(path, []byte("level: debug\nlevels: {db: trace}\n"), 0o644))
+	require.Eventually(t, func() bool { return GetLevel() == DebugLevel }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return strings.Contains(buf.String(), "applied config") }, time.Second, 5*time.Millisecond)
+	assert.Contains(t, buf.String(), `level=info msg="applied config `+path+`: level: warning -> debug, levels: map[] -> map[db:trace]" file="config.go:`)
+
+	require.NoError(t, os.WriteFile(path, []byte("level: loud\n"), 0o644))
+	require.Eventually(t, func() bool { return strings.Contains(buf.String(), "failed to apply config") }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, DebugLevel, GetLevel())
+
+	stop()
+	stop()
+}
+
+func TestDiffConfig(t *testing.T) {
+	a := DefaultConfig()
+	b := DefaultConfig()
+	assert.Equal(t, "", diffConfig(a, b))
+
+	b.Format = JSONFormat
+	b.Sampling.Initial = 10
+	assert.Equal(t, "format: text -> json, sampling: {Initial:0 Thereafter:0 Tick:0s} -> {Initial:10 Thereafter:0 Tick:0s}", diffConfig(a, b))
+}
+
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
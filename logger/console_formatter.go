@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorDim    = "\x1b[2m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[36m"
+	colorGray   = "\x1b[37m"
+)
+
+// consoleLevels are the level names, padded to the same width.
+var consoleLevels = [...]string{"PANIC", "FATAL", "ERROR", "WARN ", "INFO ", "DEBUG", "TRACE"}
+
+// consoleFormatter renders entries for people reading a terminal: the time,
+// the level aligned and coloured, the caller, the message, then the fields as
+// key=value. Times and callers are dimmed.
+type consoleFormatter struct {
+	callerPrettyfier func(*runtime.Frame) (string, string)
+	color            bool
+}
+
+func (f *consoleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	o := optionsOf(entry)
+	data := entryData(entry.Data)
+	static := o.defaultsFor(data)
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	levelColor := levelColor(entry.Level)
+	if ts, ok := o.timestamp(entry.Time); ok {
+		s, isString := ts.(string)
+		if !isString {
+			s = fmt.Sprint(ts)
+		}
+		f.appendColored(b, colorDim, s)
+		b.WriteByte(' ')
+	}
+	level := "?????"
+	if entry.Level <= logrus.TraceLevel {
+		level = consoleLevels[entry.Level]
+	}
+	f.appendColored(b, levelColor, level)
+	if entry.HasCaller() {
+		var fileVal string
+		if frame, ok := entry.Data[callerKey].(*runtime.Frame); ok {
+			_, fileVal = o.frameCaller(frame)
+		} else {
+			_, fileVal = f.callerPrettyfier(entry.Caller)
+		}
+		if fileVal != "" {
+			b.WriteByte(' ')
+			f.appendColored(b, colorDim, fileVal)
+		}
+	}
+	if entry.Message != "" {
+		b.WriteByte(' ')
+		b.WriteString(strings.TrimSuffix(entry.Message, "\n"))
+	}
+	static.each(keys, func(key string, i int) {
+		b.WriteByte(' ')
+		f.appendColored(b, levelColor, key)
+		b.WriteByte('=')
+		if i >= 0 {
+			b.Write(static.values[i])
+		} else {
+			appendValue(b, data[key])
+		}
+	})
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func (f *consoleFormatter) appendColored(b *bytes.Buffer, color, s string) {
+	if f.color {
+		b.WriteString(color)
+	}
+	b.WriteString(s)
+	if f.color {
+		b.WriteString(colorReset)
+	}
+}
+
+func levelColor(level logrus.Level) string {
+	switch level {
+	case logrus.DebugLevel, logrus.TraceLevel:
+		return colorGray
+	case logrus.WarnLevel:
+		return colorYellow
+	case logrus.ErrorLevel, logrus.FatalLevel, logrus.PanicLevel:
+		return colorRed
+	}
+	return colorBlue
+}
+
+// useColor reports whether to colour output written to w: never if NO_COLOR
+// is set, always if FORCE_COLOR is, otherwise when w is a terminal.
+func useColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a terminal: a writer whose IsTerminal
+// method says so, or a character device such as os.Stderr on a console.
+func isTerminal(w io.Writer) bool {
+	switch w := w.(type) {
+	case interface{ IsTerminal() bool }:
+		return w.IsTerminal()
+	case *os.File:
+		info, err := w.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return false
+}
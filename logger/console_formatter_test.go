@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ttyBuffer is a writer that says it is a terminal.
+type ttyBuffer struct {
+	bytes.Buffer
+}
+
+func (*ttyBuffer) IsTerminal() bool {
+	return true
+}
+
+func TestConsoleFormatter(t *testing.T) {
+	testCases := []struct {
+		color bool
+		want  string
+	}{
+		{false, "2023-10-18T12:00:00Z WARN  main.go:42 hello world count=3 empty= err=boom fields.msg=clash user=alice\n"},
+		{true, "\x1b[2m2023-10-18T12:00:00Z\x1b[0m \x1b[33mWARN \x1b[0m \x1b[2mmain.go:42\x1b[0m hello world" +
+			" \x1b[33mcount\x1b[0m=3 \x1b[33mempty\x1b[0m= \x1b[33merr\x1b[0m=boom \x1b[33mfields.msg\x1b[0m=clash \x1b[33muser\x1b[0m=alice\n"},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			formatter := &consoleFormatter{callerPrettyfier: frameCallerPrettyfier, color: tc.color}
+			got, err := formatter.Format(testEntry())
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestConsoleFormatter_levels(t *testing.T) {
+	formatter := &consoleFormatter{callerPrettyfier: frameCallerPrettyfier, color: true}
+	testCases := []struct {
+		level logrus.Level
+		want  string
+	}{
+		{logrus.PanicLevel, "\x1b[31mPANIC\x1b[0m"},
+		{logrus.FatalLevel, "\x1b[31mFATAL\x1b[0m"},
+		{logrus.ErrorLevel, "\x1b[31mERROR\x1b[0m"},
+		{logrus.WarnLevel, "\x1b[33mWARN \x1b[0m"},
+		{logrus.InfoLevel, "\x1b[36mINFO \x1b[0m"},
+		{logrus.DebugLevel, "\x1b[37mDEBUG\x1b[0m"},
+		{logrus.TraceLevel, "\x1b[37mTRACE\x1b[0m"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.level.String(), func(t *testing.T) {
+			entry := testEntry()
+			entry.Level = tc.level
+			got, err := formatter.Format(entry)
+			require.NoError(t, err)
+			assert.Contains(t, string(got), "\x1b[0m "+tc.want+" ")
+		})
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	tty := &ttyBuffer{}
+	plain := &bytes.Buffer{}
+	testCases := []struct {
+		noColor    string
+		forceColor string
+		w          io.Writer
+		want       bool
+	}{
+		{"", "", tty, true},
+		{"", "", plain, false},
+		{"", "", &lockedWriter{w: tty}, true},
+		{"1", "", tty, false},
+		{"", "1", plain, true},
+		{"", "0", plain, false},
+		{"1", "1", plain, false},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			t.Setenv("NO_COLOR", tc.noColor)
+			t.Setenv("FORCE_COLOR", tc.forceColor)
+			assert.Equal(t, tc.want, useColor(tc.w))
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	assert.False(t, isTerminal(&bytes.Buffer{}))
+	assert.True(t, isTerminal(&ttyBuffer{}))
+
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.False(t, isTerminal(f))
+}
+
+func TestSetFormat_console(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	require.NoError(t, SetFormat(ConsoleFormat))
+	defer func() {
+		_ = SetFormat(TextFormat)
+	}()
+
+	tty := &ttyBuffer{}
+	SetOutput(tty)
+	Warnf("to a terminal")
+	plain := &bytes.Buffer{}
+	SetOutput(plain)
+	Warnf("to a buffer")
+	SetOutput(os.Stderr)
+
+	assert.Regexp(t, "^\x1b\\[2m[^ ]+\x1b\\[0m \x1b\\[33mWARN \x1b\\[0m \x1b\\[2mconsole_formatter_test.go:[0-9]+\x1b\\[0m to a terminal\n$", tty.String())
+	assert.Regexp(t, `^[^ ]+ WARN  console_formatter_test.go:[0-9]+ to a buffer\n$`, plain.String())
+}
+
+func TestSetSplitOutput_console(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	require.NoError(t, SetFormat(ConsoleFormat))
+	defer func() {
+		_ = SetFormat(TextFormat)
+	}()
+
+	stdout, stderr := &bytes.Buffer{}, &ttyBuffer{}
+	SetSplitOutput(stdout, stderr, WarnLevel)
+	Infof("info")
+	Errorf("error")
+	SetOutput(os.Stderr)
+
+	assert.NotContains(t, stdout.String(), "\x1b[")
+	assert.Contains(t, stderr.String(), "\x1b[31mERROR\x1b[0m")
+}
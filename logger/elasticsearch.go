@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ElasticsearchOptions configures an ElasticsearchExporter.
+type ElasticsearchOptions struct {
+	// URL is the address of the cluster, such as http://localhost:9200, with
+	// the user and password for basic authentication if needed.
+	URL string
+	// Index names the index of each entry. Time layouts between braces are
+	// replaced by the entry time in UTC, so "logs-{2006.01.02}", the default,
+	// gives one index per day.
+	Index   string
+	Headers map[string]string // added to every request, such as Authorization: ApiKey ...
+	Client  *http.Client      // with a 10s timeout when nil
+	Batch   BatchOptions
+}
+
+// ElasticsearchExporter indexes entries with the _bulk API, in batches sent in
+// the background. Use it as the Writer of a Sink with a JSON Format, such as
+// ECSFormat: the documents are the entries rendered in the Format of the sink.
+type ElasticsearchExporter struct {
+	opts    ElasticsearchOptions
+	url     string
+	name    string // the URL without its password, for messages
+	batcher *batcher
+}
+
+func NewElasticsearchExporter(opts ElasticsearchOptions) (*ElasticsearchExporter, error) {
+	if opts.URL == "" {
+		return nil, errors.New("missing Elasticsearch URL")
+	}
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Elasticsearch URL: %w", err)
+	}
+	if opts.Index == "" {
+		opts.Index = "logs-{2006.01.02}"
+	}
+	if strings.Count(opts.Index, "{") != strings.Count(opts.Index, "}") {
+		return nil, fmt.Errorf("unbalanced braces in index: %q", opts.Index)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	x := &ElasticsearchExporter{opts: opts, url: strings.TrimSuffix(opts.URL, "/") + "/_bulk", name: "Elasticsearch " + u.Redacted()}
+	x.batcher = newBatcher(x.name, opts.Batch, x.send)
+	return x, nil
+}
+
+// Write indexes p, which must be a JSON object.
+func (x *ElasticsearchExporter) Write(p []byte) (int, error) {
+	if err := x.WriteEntry(&Entry{Time: time.Now(), Level: InfoLevel}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements EntryWriter, queueing the document p for the next
+// bulk request.
+func (x *ElasticsearchExporter) WriteEntry(entry *Entry, p []byte) error {
+	p = bytes.TrimSpace(p)
+	if len(p) == 0 || p[0] != '{' || !json.Valid(p) {
+		return errors.New("Elasticsearch documents must be JSON objects, use a JSON format")
+	}
+	return x.batcher.add(entry, p)
+}
+
+// Flush indexes the entries queued so far.
+func (x *ElasticsearchExporter) Flush(ctx context.Context) error {
+	return x.batcher.flush(ctx)
+}
+
+// Close indexes the queued entries, waiting for up to 5s, and stops the exporter.
+func (x *ElasticsearchExporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	return x.batcher.close(ctx)
+}
+
+func (x *ElasticsearchExporter) send(ctx context.Context, batch []batchItem) error {
+	var body bytes.Buffer
+	for _, item := range batch {
+		// create rather than index, which data streams require
+		action, err := json.Marshal(map[string]map[string]string{"create": {"_index": expandIndex(x.opts.Index, item.entry.Time)}})
+		if err != nil {
+			return &permanentError{err: err}
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(item.p)
+		body.WriteByte('\n')
+	}
+	resp, err := postBatch(ctx, x.opts.Client, x.url, "application/x-ndjson", x.opts.Headers, body.Bytes())
+	if err != nil {
+		return err
+	}
+
+	// the request succeeds even when some documents are rejected
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		// most likely a proxy answering in place of Elasticsearch
+		return fmt.Errorf("invalid bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	failed := 0
+	var first json.RawMessage
+	var retry []batchItem
+	for i, item := range result.Items {
+		for _, r := range item {
+			switch {
+			case r.Status/100 == 2:
+			case r.Status == http.StatusTooManyRequests && i < len(batch):
+				retry = append(retry, batch[i])
+			default:
+				if failed == 0 {
+					first = r.Error
+				}
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		stats.dropped.Add(uint64(failed))
+		fmt.Fprintf(os.Stderr, "Failed to index %d entries in %s, %s\n", failed, x.name, first)
+	}
+	if len(retry) > 0 {
+		return &partialError{err: fmt.Errorf("%d entries rejected with %d Too Many Requests", len(retry), http.StatusTooManyRequests), retry: retry}
+	}
+	return nil
+}
+
+// expandIndex replaces the time layouts between braces in index with t in UTC.
+func expandIndex(index string, t time.Time) string {
+	if !strings.Contains(index, "{") {
+		return index
+	}
+	t = t.UTC()
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(index, '{')
+		if open == -1 {
+			break
+		}
+		end := strings.IndexByte(index[open:], '}')
+		if end == -1 {
+			break
+		}
+		b.WriteString(index[:open])
+		b.WriteString(t.Format(index[open+1 : open+end]))
+		index = index[open+end+1:]
+	}
+	b.WriteString(index)
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Fields map[string]interface{}
+
+// Entry is a read-only view of a log entry, as seen by sink filters and
+// returned by Recent.
+type Entry struct {
+	Time     time.Time
+	Level    Level
+	Message  string
+	File     string
+	Line     int
+	Function string
+	Fields   Fields
+	Context  context.Context // set by Logger.WithContext, or nil
+}
+
+func newEntry(entry *logrus.Entry) *Entry {
+	e := &Entry{
+		Time:    entry.Time,
+		Level:   Level(entry.Level),
+		Message: entry.Message,
+		Fields:  Fields{},
+		Context: entry.Context,
+	}
+	for k, v := range optionsOf(entry).withDefaults(entry.Data) {
+		if !isInternalKey(k) {
+			e.Fields[k] = v
+		}
+	}
+	if entry.Caller != nil {
+		e.File = entry.Caller.File
+		e.Line = entry.Caller.Line
+		e.Function = entry.Caller.Function
+	}
+	return e
+}
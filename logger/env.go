@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// EnvPrefixVar names the variable that makes package logger call
+// ConfigureFromEnv at init, with its value as the prefix, e.g. LOGGER_ENV_PREFIX=LOG_.
+const EnvPrefixVar = "LOGGER_ENV_PREFIX"
+
+// envFile is the log file opened by the last ConfigureFromEnv, until another
+// output replaces it. Its mu is held by whatever replaces the output.
+var envFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// ConfigureFromEnv configures package logger from these variables, when set,
+// each name starting with prefix:
+//
+//	LEVEL     a level, as accepted by ParseLevel
+//	FORMAT    text, json, console, klog, gcp, ecs or otel
+//	CALLER    short or fullpath
+//	TIMEZONE  a time zone name such as UTC or Asia/Seoul, or Local
+//	OUTPUT    stdout, stderr, or a file to append to
+//
+// If any is invalid, it reports all the problems and changes nothing.
+func ConfigureFromEnv(prefix string) error {
+	var errs []error
+	invalid := func(name string, err error) {
+		errs = append(errs, fmt.Errorf("%s%s: %w", prefix, name, err))
+	}
+
+	level, hasLevel := os.LookupEnv(prefix + "LEVEL")
+	var lvl Level
+	if hasLevel {
+		var err error
+		if lvl, err = ParseLevel(level); err != nil {
+			invalid("LEVEL", err)
+		}
+	}
+	format, hasFormat := os.LookupEnv(prefix + "FORMAT")
+	if hasFormat {
+		if _, err := formatterFor(Format(format), nil, nil); err != nil {
+			invalid("FORMAT", err)
+		}
+	}
+	caller, hasCaller := os.LookupEnv(prefix + "CALLER")
+	if hasCaller && caller != "short" && caller != "fullpath" {
+		invalid("CALLER", fmt.Errorf("unknown caller mode: %q (want short or fullpath)", caller))
+	}
+	timezone, hasTimezone := os.LookupEnv(prefix + "TIMEZONE")
+	var location *time.Location
+	if hasTimezone {
+		var err error
+		if location, err = time.LoadLocation(timezone); err != nil {
+			invalid("TIMEZONE", err)
+		}
+	}
+	output, hasOutput := os.LookupEnv(prefix + "OUTPUT")
+	var w io.Writer
+	var file *os.File
+	switch output {
+	case "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	case "":
+		if hasOutput {
+			invalid("OUTPUT", errors.New("empty output"))
+		}
+	default:
+		if err := checkLogFile(output); err != nil {
+			invalid("OUTPUT", err)
+		} else if len(errs) == 0 {
+			if file, err = openLogFile(output); err != nil {
+				invalid("OUTPUT", err)
+			} else {
+				w = file
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	envFile.mu.Lock()
+	defer envFile.mu.Unlock()
+	_ = updateOptions(func(o *options) {
+		if hasLevel {
+			o.level = lvl
+		}
+		if hasCaller {
+			o.fullpath = caller == "fullpath"
+		}
+		if hasFormat {
+			o.format = Format(format)
+		}
+		if hasTimezone {
+			o.time.location = location
+		}
+		if w != nil {
+			o.output = w
+			o.errOutput = nil
+			o.splitThreshold = -1
+		}
+	})
+	if w != nil {
+		closeEnvFile()
+		envFile.file = file
+	}
+	return nil
+}
+
+// closeEnvFile closes the file of the last ConfigureFromEnv, once the entries
+// being written to it are, the output having been replaced. envFile.mu must be held.
+func closeEnvFile() {
+	if envFile.file != nil {
+		drainOutputs()
+		envFile.file.Close()
+		envFile.file = nil
+	}
+}
+
+// configureFromEnvAtInit runs ConfigureFromEnv when EnvPrefixVar is set.
+func configureFromEnvAtInit() {
+	prefix, ok := os.LookupEnv(EnvPrefixVar)
+	if !ok {
+		return
+	}
+	if err := ConfigureFromEnv(prefix); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to configure logger from environment, %v\n", err)
+	}
+}
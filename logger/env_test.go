@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kuoss/common/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetEnvConfig() {
+	SetOutput(os.Stderr)
+	SetLevel(InfoLevel)
+	SetFullpath(false)
+	_ = SetFormat(TextFormat)
+	SetTimeLocation(nil)
+}
+
+func TestConfigureFromEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("APP_LOG_LEVEL", "Debug")
+	t.Setenv("APP_LOG_FORMAT", "json")
+	t.Setenv("APP_LOG_CALLER", "fullpath")
+	t.Setenv("APP_LOG_TIMEZONE", "UTC")
+	t.Setenv("APP_LOG_OUTPUT", file)
+	defer resetEnvConfig()
+
+	require.NoError(t, ConfigureFromEnv("APP_LOG_"))
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.IsType(t, &jsonFormatter{}, loadOptions().formatter)
+	assert.True(t, loadOptions().fullpath)
+	assert.Equal(t, time.UTC, loadOptions().time.location)
+
+	Debugf("to the file")
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Regexp(t, `^\{"file":"/.*/common/logger/env_test.go:[0-9]+","level":"debug","msg":"to the file","time":"[^"]+Z"\}\n$`, string(data))
+}
+
+func TestConfigureFromEnv_partial(t *testing.T) {
+	_ = SetFormat(JSONFormat)
+	t.Setenv("LOG_CALLER", "short")
+	t.Setenv("LOG_OUTPUT", "stdout")
+	defer resetEnvConfig()
+
+	require.NoError(t, ConfigureFromEnv("LOG_"))
+	assert.Equal(t, InfoLevel, GetLevel())
+	assert.IsType(t, &jsonFormatter{}, loadOptions().formatter)
+	assert.False(t, loadOptions().fullpath)
+	assert.Equal(t, os.Stdout, loadOptions().output)
+}
+
+func TestConfigureFromEnv_closesReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	replacers := []struct {
+		name    string
+		replace func() error
+	}{
+		{"env", func() error {
+			t.Setenv("LOG_OUTPUT", filepath.Join(dir, "env.log"))
+			return ConfigureFromEnv("LOG_")
+		}},
+		{"flags", (&Flags{Level: InfoLevel, Format: TextFormat, Output: "stderr"}).Apply},
+		{"config", func() error { return ApplyConfig(DefaultConfig()) }},
+	}
+	for i, tc := range replacers {
+		t.Run(tester.CaseName(i, tc.name), func(t *testing.T) {
+			defer resetConfig()
+			t.Setenv("LOG_OUTPUT", filepath.Join(dir, "app.log"))
+			require.NoError(t, ConfigureFromEnv("LOG_"))
+			first := envFile.file
+			require.NotNil(t, first)
+
+			require.NoError(t, tc.replace())
+			_, err := first.Write([]byte("late\n"))
+			assert.ErrorIs(t, err, os.ErrClosed)
+		})
+	}
+}
+
+func TestConfigureFromEnv_invalid(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("LOG_CALLER", "long")
+	t.Setenv("LOG_TIMEZONE", "Mars/Olympus")
+	t.Setenv("LOG_OUTPUT", dir)
+
+	assert.EqualError(t, ConfigureFromEnv("LOG_"), `LOG_LEVEL: not a valid Level: "loud" (want one of panic, fatal, error, warning, info, debug, trace)
+LOG_FORMAT: unknown format: "xml"
+LOG_CALLER: unknown caller mode: "long" (want short or fullpath)
+LOG_TIMEZONE: unknown time zone Mars/Olympus
+LOG_OUTPUT: `+dir+` is a directory`)
+	assert.Equal(t, InfoLevel, GetLevel())
+	assert.Equal(t, os.Stderr, loadOptions().output)
+}
+
+func TestConfigureFromEnv_atInit(t *testing.T) {
+	t.Setenv(EnvPrefixVar, "LOG_")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_OUTPUT", "stdout")
+	stdout, _, err := tester.RunChild(func() {
+		Debugf("configured at init")
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `level=debug msg="configured at init" file="env_test.go:[0-9]+"`, stdout)
+}
+
+func TestConfigureFromEnv_atInitInvalid(t *testing.T) {
+	t.Setenv(EnvPrefixVar, "LOG_")
+	t.Setenv("LOG_LEVEL", "loud")
+	_, stderr, err := tester.RunChild(func() {
+		Infof("still running")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stderr, `Failed to configure logger from environment, LOG_LEVEL: not a valid Level: "loud"`)
+	assert.Contains(t, stderr, `msg="still running"`)
+}
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// staticFields are fields added to every entry, rendered once when they are set.
+type staticFields struct {
+	fields Fields
+	keys   []string // sorted
+	text   []byte   // " key=value ..." in logfmt
+	json   []byte   // `"key":value,...` without braces
+	values [][]byte // the logfmt value of each key, for the console format
+	klog   [][]byte // "=value" for each key, as klog renders it
+	// profiles are the fields renamed for each JSON profile
+	profiles map[*jsonProfile]*staticFields
+}
+
+// SetDefaultFields sets fields added to every entry, replacing those set before.
+// Fields given to a single entry take precedence over default fields with the same key.
+func SetDefaultFields(fields ...Fields) error {
+	merged := Fields{}
+	for _, f := range fields {
+		for k, v := range f {
+			merged[k] = v
+		}
+	}
+	return updateOptions(func(o *options) {
+		o.defaultFields = merged
+	})
+}
+
+// WithProcessInfo returns fields describing the running process: hostname, pid,
+// executable name, and the module version and VCS revision reported by the build.
+func WithProcessInfo() Fields {
+	fields := Fields{"pid": os.Getpid()}
+	if hostname, err := os.Hostname(); err == nil {
+		fields["hostname"] = hostname
+	}
+	if exe, err := os.Executable(); err == nil {
+		fields["exe"] = filepath.Base(exe)
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "" {
+			fields["version"] = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				fields["revision"] = setting.Value
+			}
+		}
+	}
+	return fields
+}
+
+func newStaticFields(fields Fields) (*staticFields, error) {
+	sf, err := newJSONFields(Fields(entryData(logrus.Fields(fields))))
+	if err != nil {
+		return nil, err
+	}
+	var text bytes.Buffer
+	for _, key := range sf.keys {
+		text.WriteByte(' ')
+		text.WriteString(key)
+		text.WriteByte('=')
+		start := text.Len()
+		appendValue(&text, sf.fields[key])
+		sf.values = append(sf.values, text.Bytes()[start:])
+
+		var klog bytes.Buffer
+		appendKlogValue(&klog, sf.fields[key])
+		sf.klog = append(sf.klog, klog.Bytes())
+	}
+	sf.text = text.Bytes()
+
+	sf.profiles = make(map[*jsonProfile]*staticFields, len(jsonProfiles))
+	for _, profile := range jsonProfiles {
+		renamed := make(Fields, len(sf.fields))
+		for k, v := range sf.fields {
+			renamed[profile.key(k)] = v
+		}
+		if sf.profiles[profile], err = newJSONFields(renamed); err != nil {
+			return nil, err
+		}
+	}
+	return sf, nil
+}
+
+// newJSONFields returns fields with their sorted keys and JSON rendering only.
+func newJSONFields(fields Fields) (*staticFields, error) {
+	sf := &staticFields{fields: fields, keys: make([]string, 0, len(fields))}
+	for k := range fields {
+		sf.keys = append(sf.keys, k)
+	}
+	sort.Strings(sf.keys)
+	var js bytes.Buffer
+	for i, key := range sf.keys {
+		value := fields[key]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			js.WriteByte(',')
+		}
+		js.Write(k)
+		js.WriteByte(':')
+		js.Write(v)
+	}
+	sf.json = js.Bytes()
+	return sf, nil
+}
+
+// defaultsFor returns the default fields to render after data. When data
+// overrides one of them, the others are merged into data and nil is returned.
+func (o *options) defaultsFor(data logrus.Fields) *staticFields {
+	return o.defaults.after(data)
+}
+
+// after returns sf, the fields to render after data, or nil once merged into
+// data when data overrides one of them.
+func (sf *staticFields) after(data logrus.Fields) *staticFields {
+	if sf == nil {
+		return nil
+	}
+	for k := range sf.fields {
+		if _, ok := data[k]; ok {
+			for k, v := range sf.fields {
+				if _, ok := data[k]; !ok {
+					data[k] = v
+				}
+			}
+			return nil
+		}
+	}
+	return sf
+}
+
+// each calls fn for keys, the sorted keys of an entry, and the keys of sf in
+// order, with i the index of the key in sf, or -1 for those of the entry.
+func (sf *staticFields) each(keys []string, fn func(key string, i int)) {
+	var static []string
+	if sf != nil {
+		static = sf.keys
+	}
+	for len(keys) > 0 || len(static) > 0 {
+		if len(static) == 0 || len(keys) > 0 && keys[0] < static[0] {
+			fn(keys[0], -1)
+			keys = keys[1:]
+			continue
+		}
+		fn(static[0], len(sf.keys)-len(static))
+		static = static[1:]
+	}
+}
+
+// spliceJSON adds the pre-encoded fields js to the JSON object b ends with,
+// followed by a newline as json.Encoder writes it.
+func spliceJSON(b *bytes.Buffer, js []byte) {
+	if len(js) == 0 {
+		return
+	}
+	b.Truncate(b.Len() - 2)
+	if !bytes.HasSuffix(b.Bytes(), []byte("{")) {
+		b.WriteByte(',')
+	}
+	b.Write(js)
+	b.WriteString("}\n")
+}
+
+// withDefaults returns the entry fields merged over the default fields.
+func (o *options) withDefaults(data logrus.Fields) logrus.Fields {
+	sf := o.defaults
+	if len(sf.fields) == 0 {
+		return data
+	}
+	merged := make(logrus.Fields, len(sf.fields)+len(data))
+	for k, v := range sf.fields {
+		merged[k] = v
+	}
+	for k, v := range data {
+		merged[k] = v
+	}
+	return merged
+}
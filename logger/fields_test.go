@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultFields(t *testing.T) {
+	require.NoError(t, SetDefaultFields(Fields{"service": "api"}, Fields{"version": "v1.2.3", "msg": "clash"}))
+	defer func() { require.NoError(t, SetDefaultFields()) }()
+
+	output := captureOutput(func() {
+		Infof("hello")
+	})
+	assert.Regexp(t, `level=info msg=hello file="fields_test.go:[0-9]+" fields.msg=clash service=api version=v1.2.3\n$`, output)
+}
+
+func TestSetDefaultFields_json(t *testing.T) {
+	require.NoError(t, SetDefaultFields(Fields{"service": "api", "pid": 42}))
+	defer func() { require.NoError(t, SetDefaultFields()) }()
+
+	buf := &bytes.Buffer{}
+	remove, err := AddSink(Sink{Writer: buf, MinLevel: InfoLevel, Format: JSONFormat})
+	require.NoError(t, err)
+	_ = captureOutput(func() {
+		Infof("hello")
+	})
+	remove()
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "api", got["service"])
+	assert.Equal(t, float64(42), got["pid"])
+	assert.Equal(t, "hello", got["msg"])
+}
+
+func TestSetDefaultFields_entryOverrides(t *testing.T) {
+	require.NoError(t, SetDefaultFields(Fields{"service": "frontend", "zone": "a"}))
+	defer func() { require.NoError(t, SetDefaultFields()) }()
+
+	entry := testEntry()
+	entry.Data = logrus.Fields{"service": "worker"}
+	for _, format := range []Format{TextFormat, JSONFormat, ConsoleFormat, KlogFormat, GCPFormat, ECSFormat, OTelFormat} {
+		formatter, err := newFormatter(format, nil)
+		require.NoError(t, err)
+		got, err := formatter.Format(entry)
+		require.NoError(t, err)
+		assert.Contains(t, string(got), "worker")
+		assert.NotContains(t, string(got), "frontend")
+		assert.Contains(t, string(got), "zone")
+	}
+
+	view := newEntry(entry)
+	assert.Equal(t, Fields{"service": "worker", "zone": "a"}, view.Fields)
+}
+
+// TestSetDefaultFields_formats checks that default fields render as if the
+// entry had them, after its own in the text format, and add no allocation
+// per field.
+func TestSetDefaultFields_formats(t *testing.T) {
+	defaults := Fields{"service": "api", "message": "clash", "code.lineno": 1, "zone": "a\nb"}
+	for _, format := range []Format{TextFormat, JSONFormat, ConsoleFormat, KlogFormat, GCPFormat, ECSFormat, OTelFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			formatter, err := newFormatter(format, nil)
+			require.NoError(t, err)
+			entry := testEntry()
+			entry.Data["logger"] = "db"
+			bare, err := formatter.Format(entry)
+			require.NoError(t, err)
+
+			withFields := testEntry()
+			withFields.Data["logger"] = "db"
+			for k, v := range defaults {
+				withFields.Data[k] = v
+			}
+			want, err := formatter.Format(withFields)
+			require.NoError(t, err)
+
+			require.NoError(t, SetDefaultFields(defaults))
+			defer func() { require.NoError(t, SetDefaultFields()) }()
+			got, err := formatter.Format(entry)
+			require.NoError(t, err)
+			switch format {
+			case TextFormat:
+				assert.ElementsMatch(t, strings.Fields(string(want)), strings.Fields(string(got)))
+			case ConsoleFormat, KlogFormat:
+				assert.Equal(t, string(want), string(got))
+			default:
+				assert.JSONEq(t, string(want), string(got))
+			}
+			assert.NotEqual(t, string(bare), string(got))
+
+			if raceEnabled {
+				return
+			}
+			allocs := testing.AllocsPerRun(10, func() { _, _ = formatter.Format(entry) })
+			more := Fields{"a1": 1, "a2": "x", "a3": true, "a4": 4.5}
+			require.NoError(t, SetDefaultFields(defaults, more))
+			// the longer output may take one more buffer growth
+			assert.LessOrEqual(t, testing.AllocsPerRun(10, func() { _, _ = formatter.Format(entry) }), allocs+1)
+		})
+	}
+}
+
+func TestSetDefaultFields_invalid(t *testing.T) {
+	err := SetDefaultFields(Fields{"ch": make(chan int)})
+	assert.EqualError(t, err, "json: unsupported type: chan int")
+}
+
+func TestWithProcessInfo(t *testing.T) {
+	fields := WithProcessInfo()
+	assert.Equal(t, os.Getpid(), fields["pid"])
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	assert.Equal(t, hostname, fields["hostname"])
+	assert.NotEmpty(t, fields["exe"])
+	assert.NotEmpty(t, fields["version"])
+}
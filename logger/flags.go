@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Flags holds the values of the command-line flags added by RegisterFlags.
+type Flags struct {
+	Level          Level
+	Format         Format
+	File           string
+	Output         string // stdout or stderr; empty means stderr
+	Split          string // a level, empty when not splitting
+	CallerFullpath bool
+}
+
+// flagsFile is the log file opened by the last Flags.Apply.
+var flagsFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// RegisterFlags adds --log-level, --log-format, --log-file, --log-output,
+// --log-split and --log-caller-fullpath to fs, defaulting to the current
+// settings. Call Apply on the result once fs has been parsed.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	o := loadOptions()
+	f := &Flags{Level: GetLevel(), Format: o.format, CallerFullpath: o.fullpath}
+	fs.Var(&f.Level, "log-level", "log level: panic, fatal, error, warning, info, debug or trace")
+	fs.Var(&f.Format, "log-format", "log format: text, json, console, klog, gcp, ecs or otel")
+	fs.StringVar(&f.File, "log-file", "", "append logs to this file instead of stderr")
+	fs.StringVar(&f.Output, "log-output", "", "write logs to stdout or stderr (default stderr)")
+	fs.StringVar(&f.Split, "log-split", "", "write entries at this level or above to stderr, and the others to the log output")
+	fs.BoolVar(&f.CallerFullpath, "log-caller-fullpath", f.CallerFullpath, "log the full path of the caller file")
+	return f
+}
+
+// Apply validates the flags together and applies them. If any is invalid,
+// or they conflict, it reports all the problems and changes nothing.
+func (f *Flags) Apply() error {
+	var errs []error
+	if f.Level > TraceLevel {
+		errs = append(errs, fmt.Errorf("--log-level: not a valid Level: %d", f.Level))
+	}
+	if _, err := formatterFor(f.Format, nil, nil); err != nil {
+		errs = append(errs, fmt.Errorf("--log-format: %w", err))
+	}
+	if f.File != "" {
+		if err := checkLogFile(f.File); err != nil {
+			errs = append(errs, fmt.Errorf("--log-file: %w", err))
+		}
+	}
+	var output io.Writer
+	switch f.Output {
+	case "", "stderr":
+		output = os.Stderr
+	case "stdout":
+		output = os.Stdout
+	default:
+		errs = append(errs, fmt.Errorf("--log-output: unknown output: %q (want stdout or stderr)", f.Output))
+	}
+	if f.File != "" && f.Output != "" {
+		errs = append(errs, errors.New("--log-file and --log-output are mutually exclusive"))
+	}
+	var split Level
+	if f.Split != "" {
+		var err error
+		if split, err = ParseLevel(f.Split); err != nil {
+			errs = append(errs, fmt.Errorf("--log-split: %w", err))
+		} else if f.File == "" && output == os.Stderr {
+			errs = append(errs, errors.New("--log-split: entries would all go to stderr; set --log-file or --log-output=stdout"))
+		}
+	}
+	var file *os.File
+	if f.File != "" && len(errs) == 0 {
+		var err error
+		if file, err = openLogFile(f.File); err != nil {
+			errs = append(errs, fmt.Errorf("--log-file: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if file != nil {
+		output = file
+	}
+	// without --log-file or --log-output, the output is left as it is
+	replace := file != nil || f.Output != ""
+	flagsFile.mu.Lock()
+	defer flagsFile.mu.Unlock()
+	envFile.mu.Lock()
+	defer envFile.mu.Unlock()
+	_ = updateOptions(func(o *options) {
+		o.level = f.Level
+		o.fullpath = f.CallerFullpath
+		o.format = f.Format
+		if f.Split != "" {
+			o.setSplitOutput(output, os.Stderr, split)
+		} else if replace {
+			o.output = output
+			o.errOutput = nil
+			o.splitThreshold = -1
+		}
+	})
+	if replace {
+		// the file of a previous Apply is closed once the entries being written to it are
+		if flagsFile.file != nil {
+			drainOutputs()
+			flagsFile.file.Close()
+		}
+		flagsFile.file = file
+		closeEnvFile()
+	}
+	return nil
+}
+
+// checkLogFile reports a log file name that can be seen to be unusable without opening it.
+func checkLogFile(name string) error {
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", name)
+	}
+	return nil
+}
+
+// openLogFile opens name for appending, creating it if needed.
+func openLogFile(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
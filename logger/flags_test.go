@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func TestRegisterFlags(t *testing.T) {
+	fs := newFlagSet()
+	f := RegisterFlags(fs)
+	assert.Equal(t, &Flags{Level: InfoLevel, Format: TextFormat}, f)
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, fs.Parse([]string{"--log-level=DEBUG", "--log-format", "json", "--log-file", file, "--log-caller-fullpath"}))
+	assert.Equal(t, &Flags{Level: DebugLevel, Format: JSONFormat, File: file, CallerFullpath: true}, f)
+
+	require.NoError(t, f.Apply())
+	defer func() {
+		SetOutput(os.Stderr)
+		SetLevel(InfoLevel)
+		_ = SetFormat(TextFormat)
+		SetFullpath(false)
+	}()
+	assert.Equal(t, DebugLevel, GetLevel())
+	assert.IsType(t, &jsonFormatter{}, loadOptions().formatter)
+	assert.True(t, loadOptions().fullpath)
+
+	Debugf("to the file")
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Regexp(t, `^\{"file":"/.*/common/logger/flags_test.go:[0-9]+","level":"debug","msg":"to the file","time":"[^"]+"\}\n$`, string(data))
+}
+
+func TestRegisterFlags_invalid(t *testing.T) {
+	testCases := []struct {
+		args      []string
+		wantError string
+	}{
+		{[]string{"--log-level=loud"}, `invalid value "loud" for flag -log-level: not a valid Level: "loud" (want one of panic, fatal, error, warning, info, debug, trace)`},
+		{[]string{"--log-format=xml"}, `invalid value "xml" for flag -log-format: unknown format: "xml"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.args[0], func(t *testing.T) {
+			fs := newFlagSet()
+			_ = RegisterFlags(fs)
+			assert.EqualError(t, fs.Parse(tc.args), tc.wantError)
+		})
+	}
+}
+
+func TestFlagsApply_invalid(t *testing.T) {
+	dir := t.TempDir()
+	f := &Flags{Level: Level(9), Format: "xml", File: dir}
+	assert.EqualError(t, f.Apply(), "--log-level: not a valid Level: 9\n--log-format: unknown format: \"xml\"\n--log-file: "+dir+" is a directory")
+
+	f = &Flags{Level: DebugLevel, Format: TextFormat, File: filepath.Join(dir, "missing", "app.log")}
+	assert.ErrorContains(t, f.Apply(), "--log-file: open "+dir+"/missing/app.log: no such file or directory")
+
+	// nothing was applied
+	assert.Equal(t, InfoLevel, GetLevel())
+}
+
+func TestFlagsApply_conflicts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	testCases := []struct {
+		flags     Flags
+		wantError string
+	}{
+		{Flags{File: file, Output: "stdout"}, "--log-file and --log-output are mutually exclusive"},
+		{Flags{Output: "stdin"}, `--log-output: unknown output: "stdin" (want stdout or stderr)`},
+		{Flags{Split: "warn"}, "--log-split: entries would all go to stderr; set --log-file or --log-output=stdout"},
+		{Flags{Output: "stderr", Split: "warn"}, "--log-split: entries would all go to stderr; set --log-file or --log-output=stdout"},
+		{Flags{Output: "stdout", Split: "loud"}, `--log-split: not a valid Level: "loud" (want one of panic, fatal, error, warning, info, debug, trace)`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.wantError, func(t *testing.T) {
+			tc.flags.Level = InfoLevel
+			tc.flags.Format = TextFormat
+			assert.EqualError(t, tc.flags.Apply(), tc.wantError)
+		})
+	}
+	_, err := os.Stat(file)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFlagsApply_split(t *testing.T) {
+	fs := newFlagSet()
+	f := RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"--log-output=stdout", "--log-split=warn"}))
+	require.NoError(t, f.Apply())
+	defer SetOutput(os.Stderr)
+
+	o := loadOptions()
+	assert.Equal(t, int32(WarnLevel), o.splitThreshold)
+	assert.Equal(t, os.Stdout, o.output.(*lockedWriter).w)
+	assert.Equal(t, os.Stderr, o.errOutput.(*lockedWriter).w)
+}
+
+func TestFlagsApply_closesPreviousFile(t *testing.T) {
+	dir := t.TempDir()
+	f := &Flags{Level: InfoLevel, Format: TextFormat, File: filepath.Join(dir, "first.log")}
+	require.NoError(t, f.Apply())
+	defer SetOutput(os.Stderr)
+	first := flagsFile.file
+
+	f.File = filepath.Join(dir, "second.log")
+	require.NoError(t, f.Apply())
+	_, err := first.Write([]byte("late\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	// applying again without an output keeps the file
+	f.File = ""
+	require.NoError(t, f.Apply())
+	Infof("still open")
+	data, err := os.ReadFile(filepath.Join(dir, "second.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "still open")
+}
@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Format string
+
+const (
+	TextFormat    Format = "text"
+	JSONFormat    Format = "json"
+	ConsoleFormat Format = "console" // aligned and coloured when writing to a terminal, for humans
+	KlogFormat    Format = "klog"    // the layout of k8s.io/klog/v2
+	GCPFormat     Format = "gcp"     // JSON for Google Cloud Logging
+	ECSFormat     Format = "ecs"     // JSON in the Elastic Common Schema
+	OTelFormat    Format = "otel"    // JSON in the OpenTelemetry log data model
+)
+
+func (format Format) String() string {
+	return string(format)
+}
+
+// Set implements flag.Value.
+func (format *Format) Set(s string) error {
+	if _, err := newFormatter(Format(s), nil); err != nil {
+		return err
+	}
+	*format = Format(s)
+	return nil
+}
+
+func (format *Format) UnmarshalText(text []byte) error {
+	return format.Set(string(text))
+}
+
+// newFormatter returns a formatter writing to w for entries whose caller has
+// already been resolved into entry.Caller (see resolveCaller).
+func newFormatter(format Format, w io.Writer) (logrus.Formatter, error) {
+	return formatterFor(format, frameCallerPrettyfier, w)
+}
+
+// formatterFor returns a formatter writing to w, rendering the caller of entries
+// without a resolved caller with callerPrettyfier.
+func formatterFor(format Format, callerPrettyfier func(*runtime.Frame) (string, string), w io.Writer) (logrus.Formatter, error) {
+	switch format {
+	case "", TextFormat:
+		return &textFormatter{callerPrettyfier: callerPrettyfier, color: useColor(w)}, nil
+	case JSONFormat:
+		return &jsonFormatter{callerPrettyfier: callerPrettyfier}, nil
+	case ConsoleFormat:
+		return &consoleFormatter{callerPrettyfier: callerPrettyfier, color: useColor(w)}, nil
+	case KlogFormat:
+		return &klogFormatter{callerPrettyfier: callerPrettyfier}, nil
+	case GCPFormat, ECSFormat, OTelFormat:
+		return &profileFormatter{callerPrettyfier: callerPrettyfier, profile: jsonProfiles[format]}, nil
+	}
+	return nil, fmt.Errorf("unknown format: %q", format)
+}
+
+// callerKey holds the *runtime.Frame of entries whose caller was resolved when
+// they were created, rather than at a fixed depth from the formatter.
+const callerKey = "\x00caller"
+
+// dumpKey marks entries written again by the recorder dump, which the
+// dispatcher leaves alone.
+const dumpKey = "\x00dump"
+
+// optionsKey holds the *options an entry is written with.
+const optionsKey = "\x00options"
+
+func isInternalKey(k string) bool {
+	return k == callerKey || k == dumpKey || k == optionsKey
+}
+
+// entryData copies the fields of an entry, prefixing those that clash with the
+// keys written by the formatters with "fields.".
+func entryData(fields logrus.Fields) logrus.Fields {
+	data := make(logrus.Fields, len(fields)+5)
+	for k, v := range fields {
+		switch k {
+		case callerKey, dumpKey, optionsKey:
+		case logrus.FieldKeyTime, logrus.FieldKeyLevel, logrus.FieldKeyMsg, logrus.FieldKeyFunc, logrus.FieldKeyFile:
+			data["fields."+k] = v
+		default:
+			data[k] = v
+		}
+	}
+	return data
+}
+
+func frameCallerPrettyfier(f *runtime.Frame) (string, string) {
+	return loadOptions().frameCaller(f)
+}
+
+func (o *options) frameCaller(f *runtime.Frame) (string, string) {
+	return "", formatCaller(f.File, f.Line, o.fullpath)
+}
+
+func formatCaller(file string, line int, fullpath bool) string {
+	return fmt.Sprintf("%s:%d", formatFile(file, fullpath), line)
+}
+
+func formatFile(file string, fullpath bool) string {
+	if !fullpath {
+		if slash := strings.LastIndex(file, "/"); slash >= 0 {
+			file = file[slash+1:]
+		}
+	}
+	return file
+}
+
+// resolveCaller returns the frame skip levels above its caller, like runtime.Caller.
+func resolveCaller(skip int) *runtime.Frame {
+	pcs := make([]uintptr, 1)
+	if runtime.Callers(skip+2, pcs) == 0 {
+		return &runtime.Frame{File: "???", Line: 1}
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return &frame
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntry() *logrus.Entry {
+	return &logrus.Entry{
+		Logger:  logger,
+		Time:    time.Date(2023, 10, 18, 12, 0, 0, 0, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "hello world",
+		Caller:  &runtime.Frame{Function: "main.main", File: "/src/app/main.go", Line: 42},
+		Data: logrus.Fields{
+			"user":  "alice",
+			"count": 3,
+			"err":   errors.New("boom"),
+			"msg":   "clash",
+			"empty": "",
+		},
+	}
+}
+
+func TestNewFormatter(t *testing.T) {
+	testCases := []struct {
+		format Format
+		want   string
+	}{
+		{"", `time="2023-10-18T12:00:00Z" level=warning msg="hello world" file="main.go:42" count=3 empty= err=boom fields.msg=clash user=alice` + "\n"},
+		{TextFormat, `time="2023-10-18T12:00:00Z" level=warning msg="hello world" file="main.go:42" count=3 empty= err=boom fields.msg=clash user=alice` + "\n"},
+		{JSONFormat, `{"count":3,"empty":"","err":"boom","fields.msg":"clash","file":"main.go:42","level":"warning","msg":"hello world","time":"2023-10-18T12:00:00Z","user":"alice"}` + "\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			formatter, err := newFormatter(tc.format, nil)
+			require.NoError(t, err)
+			got, err := formatter.Format(testEntry())
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestTextFormatter_color(t *testing.T) {
+	formatter := &textFormatter{callerPrettyfier: frameCallerPrettyfier, color: true}
+	// what the main output looked like on a terminal while it used logrus.TextFormatter
+	baseline := &logrus.TextFormatter{
+		ForceColors:      true,
+		FullTimestamp:    true,
+		TimestampFormat:  time.RFC3339,
+		CallerPrettyfier: frameCallerPrettyfier,
+	}
+	for _, level := range logrus.AllLevels {
+		t.Run(level.String(), func(t *testing.T) {
+			entry := testEntry()
+			entry.Level = level
+			want, err := baseline.Format(entry)
+			require.NoError(t, err)
+			got, err := formatter.Format(entry)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+
+	got, err := formatter.Format(testEntry())
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b[33mWARN\x1b[0m[2023-10-18T12:00:00Z]main.go:42 hello world                                  "+
+		" \x1b[33mcount\x1b[0m=3 \x1b[33mempty\x1b[0m= \x1b[33merr\x1b[0m=boom \x1b[33mfields.msg\x1b[0m=clash \x1b[33muser\x1b[0m=alice\n", string(got))
+}
+
+func TestNewFormatter_color(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	formatter, err := formatterFor(TextFormat, nil, &ttyBuffer{})
+	require.NoError(t, err)
+	assert.True(t, formatter.(*textFormatter).color)
+	formatter, err = formatterFor(TextFormat, nil, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.False(t, formatter.(*textFormatter).color)
+}
+
+func TestNeedsQuoting(t *testing.T) {
+	assert.False(t, needsQuoting(""))
+	assert.False(t, needsQuoting("abc-DEF_0.9/@^+"))
+	assert.True(t, needsQuoting("hello world"))
+	assert.True(t, needsQuoting("a=b"))
+	assert.True(t, needsQuoting(`"`))
+}
@@ -0,0 +1,17 @@
+module github.com/kuoss/common/logger/grpclogger
+
+go 1.20
+
+require (
+	github.com/kuoss/common v0.1.0
+	github.com/stretchr/testify v1.9.0
+	google.golang.org/grpc v1.64.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.9.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,113 @@
+// Package grpclogger provides a grpclog.LoggerV2 backed by package logger.
+package grpclogger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kuoss/common/logger"
+	"google.golang.org/grpc/grpclog"
+)
+
+// New returns a grpclog.LoggerV2 writing to l, for use with grpclog.SetLoggerV2.
+//
+// Info, Warning, Error and Fatal map to the levels of the same names; V(0) maps
+// to InfoLevel, V(1) to DebugLevel and V(2) and above to TraceLevel.
+// Entries are attributed to the call site in grpc, not to this package.
+func New(l *logger.Logger) grpclog.LoggerV2 {
+	return &grpcLogger{logger: l}
+}
+
+type grpcLogger struct {
+	logger *logger.Logger
+}
+
+var _ grpclog.DepthLoggerV2 = &grpcLogger{}
+
+// frames between the caller of grpclog.Info and the call to LogDepth
+const depth = 2
+
+func (g *grpcLogger) Info(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.InfoLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Infoln(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.InfoLevel, sprintln(args))
+}
+
+func (g *grpcLogger) Infof(format string, args ...interface{}) {
+	g.logger.LogDepth(depth, logger.InfoLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Warning(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.WarnLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Warningln(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.WarnLevel, sprintln(args))
+}
+
+func (g *grpcLogger) Warningf(format string, args ...interface{}) {
+	g.logger.LogDepth(depth, logger.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Error(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.ErrorLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Errorln(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.ErrorLevel, sprintln(args))
+}
+
+func (g *grpcLogger) Errorf(format string, args ...interface{}) {
+	g.logger.LogDepth(depth, logger.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) Fatal(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.FatalLevel, fmt.Sprint(args...))
+}
+
+func (g *grpcLogger) Fatalln(args ...interface{}) {
+	g.logger.LogDepth(depth, logger.FatalLevel, sprintln(args))
+}
+
+func (g *grpcLogger) Fatalf(format string, args ...interface{}) {
+	g.logger.LogDepth(depth, logger.FatalLevel, fmt.Sprintf(format, args...))
+}
+
+func (g *grpcLogger) V(l int) bool {
+	return g.logger.Enabled(vLevel(l))
+}
+
+// The depth methods are called by grpc's internal grpclog package, so depth
+// counts the frames above it.
+
+func (g *grpcLogger) InfoDepth(d int, args ...interface{}) {
+	g.logger.LogDepth(d+depth, logger.InfoLevel, sprintln(args))
+}
+
+func (g *grpcLogger) WarningDepth(d int, args ...interface{}) {
+	g.logger.LogDepth(d+depth, logger.WarnLevel, sprintln(args))
+}
+
+func (g *grpcLogger) ErrorDepth(d int, args ...interface{}) {
+	g.logger.LogDepth(d+depth, logger.ErrorLevel, sprintln(args))
+}
+
+func (g *grpcLogger) FatalDepth(d int, args ...interface{}) {
+	g.logger.LogDepth(d+depth, logger.FatalLevel, sprintln(args))
+}
+
+func vLevel(l int) logger.Level {
+	switch {
+	case l <= 0:
+		return logger.InfoLevel
+	case l == 1:
+		return logger.DebugLevel
+	}
+	return logger.TraceLevel
+}
+
+func sprintln(args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
@@ -0,0 +1,82 @@
+package grpclogger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/kuoss/common/logger"
+	"github.com/kuoss/common/tester"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/grpclog"
+)
+
+func init() {
+	grpclog.SetLoggerV2(New(logger.Named("grpc")))
+}
+
+func captureOutput(f func()) string {
+	buf := &bytes.Buffer{}
+	logger.SetOutput(buf)
+	f()
+	logger.SetOutput(os.Stderr)
+	return buf.String()
+}
+
+func TestLoggerV2(t *testing.T) {
+	testCases := []struct {
+		log  func()
+		want string
+	}{
+		{func() { grpclog.Info("a", 1) }, `level=info msg=a1`},
+		{func() { grpclog.Infoln("a", 1) }, `level=info msg="a 1"`},
+		{func() { grpclog.Infof("a=%d", 1) }, `level=info msg="a=1"`},
+		{func() { grpclog.Warning("a") }, `level=warning msg=a`},
+		{func() { grpclog.Warningln("a", "b") }, `level=warning msg="a b"`},
+		{func() { grpclog.Warningf("a=%d", 1) }, `level=warning msg="a=1"`},
+		{func() { grpclog.Error("a") }, `level=error msg=a`},
+		{func() { grpclog.Errorln("a", "b") }, `level=error msg="a b"`},
+		{func() { grpclog.Errorf("a=%d", 1) }, `level=error msg="a=1"`},
+	}
+	for i, tc := range testCases {
+		t.Run(tester.CaseName(i, tc.want), func(t *testing.T) {
+			output := captureOutput(tc.log)
+			assert.Regexp(t, `^time="[^"]+" `+tc.want+` file="grpclogger_test.go:[0-9]+" logger=grpc\n$`, output)
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	var line int
+	output := captureOutput(func() {
+		_, _, line, _ = runtime.Caller(0)
+		grpclog.Component("transport").Infof("closing: %v", "EOF") // line+1
+	})
+	assert.Contains(t, output, fmt.Sprintf(`level=info msg="[transport] closing: EOF" file="grpclogger_test.go:%d" logger=grpc`, line+1))
+
+	output = captureOutput(func() {
+		_, _, line, _ = runtime.Caller(0)
+		grpclog.Component("core").Warning("warned") // line+1
+	})
+	assert.Contains(t, output, fmt.Sprintf(`level=warning msg="[core] warned" file="grpclogger_test.go:%d" logger=grpc`, line+1))
+}
+
+func TestV(t *testing.T) {
+	assert.True(t, grpclog.V(0))
+	assert.False(t, grpclog.V(1))
+
+	logger.SetLevelFor("grpc", logger.TraceLevel)
+	defer logger.UnsetLevelFor("grpc")
+	assert.True(t, grpclog.V(1))
+	assert.True(t, grpclog.V(2))
+}
+
+func TestFatal(t *testing.T) {
+	_, output, err := tester.RunChild(func() {
+		grpclog.Fatalf("fatal=%d", 1)
+	})
+	assert.Regexp(t, `level=fatal msg="fatal=1" file="grpclogger_test.go:[0-9]+" logger=grpc`, output)
+	assert.Error(t, err, "exit status 1")
+}
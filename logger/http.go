@@ -0,0 +1,214 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// HTTPOptions configures HTTPMiddleware. The zero value is usable.
+type HTTPOptions struct {
+	// Logger is the parent of the request-scoped loggers. Defaults to Named("http").
+	Logger *Logger
+
+	// StatusLevels maps a status class (1 to 5) to the level of the access entry.
+	// Classes not listed log at InfoLevel, except 4 (WarnLevel) and 5 (ErrorLevel).
+	StatusLevels map[int]Level
+
+	// ExcludePaths lists paths, or prefixes ending in "/", that get no access entry.
+	ExcludePaths []string
+
+	// ExcludeHeaders skips the access entry of requests carrying one of these
+	// headers with the given value, or with any value if it is empty.
+	ExcludeHeaders map[string]string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx by NewContext or HTTPMiddleware,
+// or an unnamed logger if there is none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return &Logger{}
+}
+
+// HTTPMiddleware propagates the X-Request-ID header of a request, or assigns one,
+// makes a logger carrying it available through FromContext(r.Context()), and
+// writes one access entry per request once next has returned or panicked.
+func HTTPMiddleware(next http.Handler, opts HTTPOptions) http.Handler {
+	parent := opts.Logger
+	if parent == nil {
+		parent = Named("http")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		l := parent.WithFields(Fields{"request_id": id})
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			// a panicking handler is logged with status 500, unless it sent
+			// its headers already, then panics on
+			p := recover()
+			if p != nil && !rw.hijacked && !rw.wroteHeader {
+				rw.status = http.StatusInternalServerError
+			}
+			opts.log(l, r, rw, start)
+			if p != nil {
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(rw, r.WithContext(NewContext(r.Context(), l)))
+	})
+}
+
+// log writes the access entry of r.
+func (opts *HTTPOptions) log(l *Logger, r *http.Request, rw *responseWriter, start time.Time) {
+	if opts.excluded(r) {
+		return
+	}
+	level := opts.statusLevel(rw.status)
+	o := loadOptions()
+	target := routeAt(o, level, l.level(o))
+	if target == discard {
+		return
+	}
+	l.WithFields(Fields{
+		"method":   r.Method,
+		"path":     r.URL.Path,
+		"status":   rw.status,
+		"bytes":    rw.bytes,
+		"duration": time.Since(start),
+		"remote":   r.RemoteAddr,
+	}).entry(target, resolveCaller(1)).Log(logrus.Level(level), "http request")
+}
+
+func (o *HTTPOptions) statusLevel(status int) Level {
+	class := status / 100
+	if level, ok := o.StatusLevels[class]; ok {
+		return level
+	}
+	switch class {
+	case 4:
+		return WarnLevel
+	case 5:
+		return ErrorLevel
+	}
+	return InfoLevel
+}
+
+func (o *HTTPOptions) excluded(r *http.Request) bool {
+	for _, path := range o.ExcludePaths {
+		if r.URL.Path == path || (strings.HasSuffix(path, "/") && strings.HasPrefix(r.URL.Path, path)) {
+			return true
+		}
+	}
+	for name, value := range o.ExcludeHeaders {
+		if got := r.Header.Values(name); len(got) > 0 && (value == "" || contains(got, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// responseWriter records the status and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack implements http.Hijacker if the underlying writer does.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// ReadFrom lets io.Copy use the io.ReaderFrom of the underlying writer, such
+// as sendfile for files.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.wroteHeader = true
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.bytes += int(n)
+	return n, err
+}
@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kuoss/common/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(handler http.Handler, r *http.Request) (*httptest.ResponseRecorder, string) {
+	rec := httptest.NewRecorder()
+	output := captureOutput(func() {
+		handler.ServeHTTP(rec, r)
+	})
+	return rec, output
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var id string
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = r.Header.Get(RequestIDHeader)
+		FromContext(r.Context()).Infof("handling")
+		_, _ = w.Write([]byte("hello"))
+	}), HTTPOptions{})
+
+	r := httptest.NewRequest("GET", "/hello", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	rec, output := serve(handler, r)
+
+	require.Len(t, id, 32)
+	assert.Equal(t, id, rec.Header().Get(RequestIDHeader))
+	assert.Regexp(t, `level=info msg=handling file="http_test.go:[0-9]+" logger=http request_id=`+id+`\n`, output)
+	assert.Regexp(t, `level=info msg="http request" file="http.go:[0-9]+" bytes=5 duration=[^ ]+ logger=http method=GET path=/hello remote="192.0.2.1:1234" request_id=`+id+` status=200\n$`, output)
+}
+
+func TestHTTPMiddleware_propagateRequestID(t *testing.T) {
+	handler := HTTPMiddleware(http.NotFoundHandler(), HTTPOptions{Logger: Named("api")})
+	r := httptest.NewRequest("GET", "/missing", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	rec, output := serve(handler, r)
+
+	assert.Equal(t, "abc", rec.Header().Get(RequestIDHeader))
+	assert.Regexp(t, `level=warning msg="http request" .* logger=api method=GET path=/missing .* request_id=abc status=404\n$`, output)
+}
+
+func TestHTTPMiddleware_statusLevels(t *testing.T) {
+	testCases := []struct {
+		status int
+		levels map[int]Level
+		want   string
+	}{
+		{200, nil, "level=info"},
+		{301, nil, "level=info"},
+		{404, nil, "level=warning"},
+		{503, nil, "level=error"},
+		{404, map[int]Level{4: InfoLevel}, "level=info"},
+		{200, map[int]Level{2: DebugLevel}, ""},
+	}
+	for i, tc := range testCases {
+		t.Run(tester.CaseName(i, tc.status, tc.levels), func(t *testing.T) {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			}), HTTPOptions{StatusLevels: tc.levels})
+			_, output := serve(handler, httptest.NewRequest("GET", "/", nil))
+			if tc.want == "" {
+				assert.Empty(t, output)
+				return
+			}
+			assert.Contains(t, output, tc.want+` msg="http request"`)
+		})
+	}
+}
+
+func TestHTTPMiddleware_exclusions(t *testing.T) {
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), HTTPOptions{
+		ExcludePaths:   []string{"/healthz", "/static/"},
+		ExcludeHeaders: map[string]string{"User-Agent": "kube-probe", "X-Internal": ""},
+	})
+	testCases := []struct {
+		path   string
+		header map[string]string
+		want   bool
+	}{
+		{"/healthz", nil, false},
+		{"/healthz/live", nil, true},
+		{"/static/app.js", nil, false},
+		{"/static", nil, true},
+		{"/", map[string]string{"User-Agent": "kube-probe"}, false},
+		{"/", map[string]string{"User-Agent": "curl"}, true},
+		{"/", map[string]string{"X-Internal": "1"}, false},
+		{"/", nil, true},
+	}
+	for i, tc := range testCases {
+		t.Run(tester.CaseName(i, tc.path, tc.header), func(t *testing.T) {
+			r := httptest.NewRequest("GET", tc.path, nil)
+			for k, v := range tc.header {
+				r.Header.Set(k, v)
+			}
+			_, output := serve(handler, r)
+			if tc.want {
+				assert.Contains(t, output, `msg="http request"`)
+			} else {
+				assert.Empty(t, output)
+			}
+		})
+	}
+}
+
+func TestHTTPMiddleware_panic(t *testing.T) {
+	testCases := []struct {
+		status int // written before panicking, if not 0
+		want   string
+	}{
+		{0, `level=error msg="http request" .* status=500\n$`},
+		// the client got the status sent before the panic
+		{http.StatusAccepted, `level=info msg="http request" .* status=202\n$`},
+	}
+	for i, tc := range testCases {
+		t.Run(tester.CaseName(i, tc.status), func(t *testing.T) {
+			handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+				}
+				panic("boom")
+			}), HTTPOptions{})
+
+			var recovered interface{}
+			output := captureOutput(func() {
+				defer func() { recovered = recover() }()
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			})
+			assert.Equal(t, "boom", recovered)
+			assert.Regexp(t, tc.want, output)
+		})
+	}
+}
+
+// hijackRecorder is a ResponseRecorder whose connection can be taken over.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+func TestHTTPMiddleware_hijack(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		require.True(t, ok)
+		conn, _, err := hijacker.Hijack()
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n\r\n"))
+	}), HTTPOptions{})
+
+	received := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(client)
+		received <- string(b)
+	}()
+	output := captureOutput(func() {
+		rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/ws", nil))
+	})
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n\r\n", <-received)
+	assert.Regexp(t, `level=info msg="http request" .* path=/ws .*\n$`, output)
+}
+
+func TestHTTPMiddleware_readFrom(t *testing.T) {
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// strings.Reader implements io.WriterTo, which io.Copy would prefer
+		_, _ = io.Copy(w, struct{ io.Reader }{strings.NewReader("hello")})
+	}), HTTPOptions{})
+
+	rec, output := serve(handler, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "hello", rec.Body.String())
+	assert.Contains(t, output, "bytes=5")
+}
+
+func TestFromContext(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, &Logger{}, FromContext(r.Context()))
+
+	l := Named("x")
+	assert.Same(t, l, FromContext(NewContext(r.Context(), l)))
+}
@@ -0,0 +1,196 @@
+//go:build linux
+
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// JournalSocket is where journald listens for the native protocol.
+const JournalSocket = "/run/systemd/journal/socket"
+
+// JournalOptions configures a JournalWriter.
+type JournalOptions struct {
+	Socket     string // JournalSocket when empty
+	Identifier string // SYSLOG_IDENTIFIER; the program name when empty
+	// Fallback receives the entries that cannot be sent; os.Stderr when nil.
+	Fallback io.Writer
+}
+
+// JournalWriter sends entries to journald with its native protocol, keeping
+// their level as PRIORITY, their caller as CODE_FILE, CODE_LINE and CODE_FUNC,
+// and their fields as journal fields named in upper case. Use it as the Writer
+// of a Sink; the message is sent as is, so the Format of the sink only matters
+// for the entries written to the fallback while journald cannot be reached.
+type JournalWriter struct {
+	opts JournalOptions
+	addr *net.UnixAddr
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+func NewJournalWriter(opts JournalOptions) (*JournalWriter, error) {
+	if opts.Socket == "" {
+		opts.Socket = JournalSocket
+	}
+	if opts.Identifier == "" {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+	if opts.Fallback == nil {
+		opts.Fallback = os.Stderr
+	}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &JournalWriter{
+		opts: opts,
+		addr: &net.UnixAddr{Name: opts.Socket, Net: "unixgram"},
+		conn: conn,
+	}, nil
+}
+
+// Write sends p as the message of an info entry.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	if err := w.WriteEntry(&Entry{Level: InfoLevel, Message: strings.TrimSuffix(string(p), "\n")}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements EntryWriter. Entries too large for a datagram are
+// passed in a sealed memfd, as journald expects.
+func (w *JournalWriter) WriteEntry(entry *Entry, p []byte) error {
+	data := w.serialize(entry)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return errors.New("journal writer closed")
+	}
+	_, _, err := w.conn.WriteMsgUnix(data, nil, w.addr)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		err = w.sendMemfd(data)
+	}
+	if err != nil {
+		_, err = w.opts.Fallback.Write(p)
+	}
+	return err
+}
+
+// Close releases the socket used to reach journald.
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *JournalWriter) sendMemfd(data []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("failed to create memfd, %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "journal-entry")
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("failed to seal memfd, %w", err)
+	}
+	_, _, err = w.conn.WriteMsgUnix(nil, syscall.UnixRights(int(f.Fd())), w.addr)
+	return err
+}
+
+// journalReserved are the fields set by JournalWriter itself, or trusted ones
+// journald would ignore; entry fields with these names get a FIELDS_ prefix.
+var journalReserved = map[string]bool{
+	"MESSAGE":           true,
+	"PRIORITY":          true,
+	"SYSLOG_IDENTIFIER": true,
+	"CODE_FILE":         true,
+	"CODE_LINE":         true,
+	"CODE_FUNC":         true,
+}
+
+func (w *JournalWriter) serialize(entry *Entry) []byte {
+	severity := 7
+	if entry.Level <= TraceLevel {
+		severity = syslogSeverities[entry.Level]
+	}
+	var b []byte
+	b = appendJournalField(b, "MESSAGE", entry.Message)
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(severity))
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", w.opts.Identifier)
+	if entry.File != "" {
+		b = appendJournalField(b, "CODE_FILE", entry.File)
+		b = appendJournalField(b, "CODE_LINE", strconv.Itoa(entry.Line))
+	}
+	if entry.Function != "" {
+		b = appendJournalField(b, "CODE_FUNC", entry.Function)
+	}
+
+	keys := make([]string, 0, len(entry.Fields))
+	for k := range entry.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := journalFieldName(k)
+		if name == "" {
+			continue
+		}
+		if journalReserved[name] {
+			name = "FIELDS_" + name
+		}
+		b = appendJournalField(b, name, fmt.Sprint(entry.Fields[k]))
+	}
+	return b
+}
+
+// journalFieldName turns key into a valid journal field name: upper case
+// letters, digits and underscores, not starting with an underscore or a digit.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+	s := strings.TrimLeft(string(name), "_0123456789")
+	if len(s) > 64 {
+		s = s[:64]
+	}
+	return s
+}
+
+// appendJournalField writes KEY=value, or for values with newlines the key,
+// the length of the value as a little-endian uint64 and the value.
+func appendJournalField(b []byte, key, value string) []byte {
+	b = append(b, key...)
+	if strings.IndexByte(value, '\n') == -1 {
+		b = append(b, '=')
+	} else {
+		b = append(b, '\n')
+		b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	}
+	b = append(b, value...)
+	return append(b, '\n')
+}
@@ -0,0 +1,38 @@
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+	"io"
+)
+
+// JournalSocket is where journald listens for the native protocol.
+const JournalSocket = "/run/systemd/journal/socket"
+
+// JournalOptions configures a JournalWriter.
+type JournalOptions struct {
+	Socket     string // JournalSocket when empty
+	Identifier string // SYSLOG_IDENTIFIER; the program name when empty
+	// Fallback receives the entries that cannot be sent; os.Stderr when nil.
+	Fallback io.Writer
+}
+
+// JournalWriter sends entries to journald, which only runs on Linux.
+type JournalWriter struct{}
+
+func NewJournalWriter(opts JournalOptions) (*JournalWriter, error) {
+	return nil, errors.New("journald is only available on linux")
+}
+
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("journald is only available on linux")
+}
+
+func (w *JournalWriter) WriteEntry(entry *Entry, p []byte) error {
+	return errors.New("journald is only available on linux")
+}
+
+func (w *JournalWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,126 @@
+//go:build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// journalStandIn listens like journald on a socket in a temporary directory.
+func journalStandIn(t *testing.T) (string, *net.UnixConn) {
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadBuffer(1<<20))
+	t.Cleanup(func() { conn.Close() })
+	return path, conn
+}
+
+// readJournalEntry reads an entry the way journald does, from the datagram
+// or from the memfd passed with it.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	require.NoError(t, err)
+	data := buf[:n]
+	if oobn > 0 {
+		messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		fds, err := syscall.ParseUnixRights(&messages[0])
+		require.NoError(t, err)
+		require.Len(t, fds, 1)
+		f := os.NewFile(uintptr(fds[0]), "memfd")
+		defer f.Close()
+		data, err = io.ReadAll(io.NewSectionReader(f, 0, 1<<30))
+		require.NoError(t, err)
+	}
+
+	fields := map[string]string{}
+	for len(data) > 0 {
+		line := bytes.IndexByte(data, '\n')
+		require.NotEqual(t, -1, line)
+		if eq := bytes.IndexByte(data[:line], '='); eq != -1 {
+			fields[string(data[:eq])] = string(data[eq+1 : line])
+			data = data[line+1:]
+			continue
+		}
+		key := string(data[:line])
+		size := binary.LittleEndian.Uint64(data[line+1:])
+		value := data[line+9 : line+9+int(size)]
+		fields[key] = string(value)
+		data = data[line+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestJournalWriter(t *testing.T) {
+	path, conn := journalStandIn(t)
+	w, err := NewJournalWriter(JournalOptions{Socket: path, Identifier: "app"})
+	require.NoError(t, err)
+	defer w.Close()
+	remove, err := AddSink(Sink{Writer: w, MinLevel: WarnLevel})
+	require.NoError(t, err)
+	defer remove()
+
+	Named("db").WithFields(Fields{"user-id": 42, "query": "SELECT 1\nFROM dual", "message": "clash"}).Warnf("slow query")
+
+	fields := readJournalEntry(t, conn)
+	assert.Equal(t, "slow query", fields["MESSAGE"])
+	assert.Equal(t, "4", fields["PRIORITY"])
+	assert.Equal(t, "app", fields["SYSLOG_IDENTIFIER"])
+	assert.True(t, strings.HasSuffix(fields["CODE_FILE"], "/journald_test.go"), fields["CODE_FILE"])
+	assert.NotEmpty(t, fields["CODE_LINE"])
+	assert.Equal(t, "github.com/kuoss/common/logger.TestJournalWriter", fields["CODE_FUNC"])
+	assert.Equal(t, "db", fields["LOGGER"])
+	assert.Equal(t, "42", fields["USER_ID"])
+	assert.Equal(t, "SELECT 1\nFROM dual", fields["QUERY"])
+	assert.Equal(t, "clash", fields["FIELDS_MESSAGE"])
+}
+
+func TestJournalWriter_memfd(t *testing.T) {
+	path, conn := journalStandIn(t)
+	w, err := NewJournalWriter(JournalOptions{Socket: path, Identifier: "app"})
+	require.NoError(t, err)
+	defer w.Close()
+
+	large := strings.Repeat("x", 4<<20)
+	require.NoError(t, w.WriteEntry(&Entry{Level: ErrorLevel, Message: "large", Fields: Fields{"payload": large}}, nil))
+
+	fields := readJournalEntry(t, conn)
+	assert.Equal(t, "large", fields["MESSAGE"])
+	assert.Equal(t, "3", fields["PRIORITY"])
+	assert.Equal(t, large, fields["PAYLOAD"])
+}
+
+func TestJournalWriter_fallback(t *testing.T) {
+	fallback := &bytes.Buffer{}
+	w, err := NewJournalWriter(JournalOptions{Socket: filepath.Join(t.TempDir(), "missing"), Fallback: fallback})
+	require.NoError(t, err)
+	defer w.Close()
+	_, err = w.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", fallback.String())
+}
+
+func TestJournalFieldName(t *testing.T) {
+	assert.Equal(t, "USER_ID", journalFieldName("user-id"))
+	assert.Equal(t, "HTTP_STATUS", journalFieldName("http.status"))
+	assert.Equal(t, "PRIVATE", journalFieldName("_private"))
+	assert.Equal(t, "X", journalFieldName("1x"))
+	assert.Equal(t, "", journalFieldName("..."))
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+)
+
+// jsonFormatter renders entries as one JSON object per line, using the same
+// keys as logrus.JSONFormatter.
+type jsonFormatter struct {
+	callerPrettyfier func(*runtime.Frame) (string, string)
+}
+
+func (f *jsonFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	o := optionsOf(entry)
+	data := entryData(entry.Data)
+	static := o.defaultsFor(data)
+	for k, v := range data {
+		if err, ok := v.(error); ok {
+			// encoding/json would render most errors as {}
+			data[k] = err.Error()
+		}
+	}
+	if ts, ok := o.timestamp(entry.Time); ok {
+		data[logrus.FieldKeyTime] = ts
+	}
+	data[logrus.FieldKeyLevel] = entry.Level.String()
+	data[logrus.FieldKeyMsg] = entry.Message
+	if entry.HasCaller() {
+		var funcVal, fileVal string
+		if frame, ok := entry.Data[callerKey].(*runtime.Frame); ok {
+			funcVal, fileVal = o.frameCaller(frame)
+		} else {
+			funcVal, fileVal = f.callerPrettyfier(entry.Caller)
+		}
+		if funcVal != "" {
+			data[logrus.FieldKeyFunc] = funcVal
+		}
+		if fileVal != "" {
+			data[logrus.FieldKeyFile] = fileVal
+		}
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	if err := json.NewEncoder(b).Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+	if static != nil {
+		spliceJSON(b, static.json)
+	}
+	return b.Bytes(), nil
+}
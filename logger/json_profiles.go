@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// profileEntry is what a JSON profile needs to know about an entry.
+type profileEntry struct {
+	time     time.Time
+	level    logrus.Level
+	message  string
+	file     string // empty without a caller
+	line     int
+	function string // empty unless the caller was resolved
+	fields   logrus.Fields
+}
+
+// jsonProfile maps an entry onto the JSON object of a logging schema.
+type jsonProfile struct {
+	// reserved are the keys the schema sets next to the fields; fields using
+	// them are renamed with the "fields." prefix.
+	reserved []string
+	// nested is the key of the object holding the fields, when not at the top level.
+	nested string
+	record func(e *profileEntry) map[string]interface{}
+}
+
+var jsonProfiles = map[Format]*jsonProfile{
+	GCPFormat:  {reserved: []string{"severity", "message", "time", "logging.googleapis.com/sourceLocation"}, record: gcpRecord},
+	ECSFormat:  {reserved: []string{"@timestamp", "log.level", "message", "log.origin", "ecs.version"}, record: ecsRecord},
+	OTelFormat: {reserved: []string{"Timestamp", "SeverityText", "SeverityNumber", "Body", "Attributes", "code.filepath", "code.lineno", "code.function"}, nested: "Attributes", record: otelRecord},
+}
+
+// key returns the name of a field with key k in the profile.
+func (p *jsonProfile) key(k string) string {
+	for _, reserved := range p.reserved {
+		if k == reserved {
+			return "fields." + k
+		}
+	}
+	return k
+}
+
+// profileFormatter renders entries as one JSON object per line in the schema of its profile.
+type profileFormatter struct {
+	callerPrettyfier func(*runtime.Frame) (string, string)
+	profile          *jsonProfile
+}
+
+func (f *profileFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	o := optionsOf(entry)
+	e := &profileEntry{
+		time:    entry.Time,
+		level:   entry.Level,
+		message: entry.Message,
+		fields:  make(logrus.Fields, len(entry.Data)),
+	}
+	for k, v := range entry.Data {
+		if isInternalKey(k) {
+			continue
+		}
+		if err, ok := v.(error); ok {
+			// encoding/json would render most errors as {}
+			v = err.Error()
+		}
+		e.fields[f.profile.key(k)] = v
+	}
+	static := o.defaults.profiles[f.profile].after(e.fields)
+	if entry.HasCaller() {
+		var fileVal string
+		if frame, ok := entry.Data[callerKey].(*runtime.Frame); ok {
+			_, fileVal = o.frameCaller(frame)
+			e.function = frame.Function
+		} else {
+			_, fileVal = f.callerPrettyfier(entry.Caller)
+		}
+		if colon := strings.LastIndex(fileVal, ":"); colon >= 0 {
+			e.file = fileVal[:colon]
+			e.line, _ = strconv.Atoi(fileVal[colon+1:])
+		}
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	record := f.profile.record(e)
+	if static != nil && f.profile.nested != "" {
+		// the pre-encoded default fields go in the nested object, encoded first
+		var nested bytes.Buffer
+		if err := json.NewEncoder(&nested).Encode(e.fields); err != nil {
+			return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+		}
+		spliceJSON(&nested, static.json)
+		record[f.profile.nested] = json.RawMessage(nested.Bytes())
+		static = nil
+	}
+	if err := json.NewEncoder(b).Encode(record); err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON, %w", err)
+	}
+	if static != nil {
+		spliceJSON(b, static.json)
+	}
+	return b.Bytes(), nil
+}
+
+// gcpSeverities are the LogSeverity names of Cloud Logging, by level.
+var gcpSeverities = [...]string{"ALERT", "CRITICAL", "ERROR", "WARNING", "INFO", "DEBUG", "DEBUG"}
+
+// gcpRecord follows https://cloud.google.com/logging/docs/structured-logging.
+func gcpRecord(e *profileEntry) map[string]interface{} {
+	record := e.fields
+	record["severity"] = levelName(gcpSeverities[:], e.level)
+	record["message"] = e.message
+	// the schema wants RFC 3339, whatever SetTimeLayout says
+	record["time"] = e.time.Format(time.RFC3339Nano)
+	if e.file != "" {
+		location := map[string]string{"file": e.file, "line": strconv.Itoa(e.line)}
+		if e.function != "" {
+			location["function"] = e.function
+		}
+		record["logging.googleapis.com/sourceLocation"] = location
+	}
+	return record
+}
+
+var ecsLevels = [...]string{"panic", "fatal", "error", "warn", "info", "debug", "trace"}
+
+// ecsRecord follows https://www.elastic.co/guide/en/ecs-logging/overview/current/intro.html,
+// the way the ecs-logging libraries do.
+func ecsRecord(e *profileEntry) map[string]interface{} {
+	record := e.fields
+	record["log.level"] = levelName(ecsLevels[:], e.level)
+	record["message"] = e.message
+	record["ecs.version"] = "1.6.0"
+	record["@timestamp"] = e.time.UTC().Format(time.RFC3339Nano)
+	if e.file != "" {
+		origin := map[string]interface{}{"file.name": e.file, "file.line": e.line}
+		if e.function != "" {
+			origin["function"] = e.function
+		}
+		record["log.origin"] = origin
+	}
+	return record
+}
+
+var (
+	otelSeverityTexts   = [...]string{"PANIC", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE"}
+	otelSeverityNumbers = [...]int{24, 21, 17, 13, 9, 5, 1}
+)
+
+// otelRecord follows https://opentelemetry.io/docs/specs/otel/logs/data-model/,
+// putting the fields and the caller in Attributes.
+func otelRecord(e *profileEntry) map[string]interface{} {
+	attributes := e.fields
+	if e.file != "" {
+		attributes["code.filepath"] = e.file
+		attributes["code.lineno"] = e.line
+		if e.function != "" {
+			attributes["code.function"] = e.function
+		}
+	}
+	record := map[string]interface{}{
+		"Timestamp":    e.time.UnixNano(),
+		"SeverityText": levelName(otelSeverityTexts[:], e.level),
+		"Body":         e.message,
+	}
+	if e.level <= logrus.TraceLevel {
+		record["SeverityNumber"] = otelSeverityNumbers[e.level]
+	}
+	if len(attributes) > 0 {
+		record["Attributes"] = attributes
+	}
+	return record
+}
+
+func levelName(names []string, level logrus.Level) string {
+	if int(level) < len(names) {
+		return names[level]
+	}
+	return "UNKNOWN"
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileFormatter(t *testing.T) {
+	testCases := []struct {
+		format Format
+		want   string
+	}{
+		{GCPFormat, `{"count":3,"empty":"","err":"boom","logging.googleapis.com/sourceLocation":{"file":"main.go","line":"42"},"message":"hello world","msg":"clash","severity":"WARNING","time":"2023-10-18T12:00:00Z","user":"alice"}` + "\n"},
+		{ECSFormat, `{"@timestamp":"2023-10-18T12:00:00Z","count":3,"ecs.version":"1.6.0","empty":"","err":"boom","log.level":"warn","log.origin":{"file.line":42,"file.name":"main.go"},"message":"hello world","msg":"clash","user":"alice"}` + "\n"},
+		{OTelFormat, `{"Attributes":{"code.filepath":"main.go","code.lineno":42,"count":3,"empty":"","err":"boom","msg":"clash","user":"alice"},"Body":"hello world","SeverityNumber":13,"SeverityText":"WARN","Timestamp":1697630400000000000}` + "\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			formatter, err := newFormatter(tc.format, nil)
+			require.NoError(t, err)
+			got, err := formatter.Format(testEntry())
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestProfileFormatter_resolvedCaller(t *testing.T) {
+	testCases := []struct {
+		format Format
+		want   string
+	}{
+		{GCPFormat, `{"logging.googleapis.com/sourceLocation":{"file":"main.go","function":"main.main","line":"42"},"message":"hello world","severity":"WARNING","time":"2023-10-18T12:00:00Z"}` + "\n"},
+		{ECSFormat, `{"@timestamp":"2023-10-18T12:00:00Z","ecs.version":"1.6.0","log.level":"warn","log.origin":{"file.line":42,"file.name":"main.go","function":"main.main"},"message":"hello world"}` + "\n"},
+		{OTelFormat, `{"Attributes":{"code.filepath":"main.go","code.function":"main.main","code.lineno":42},"Body":"hello world","SeverityNumber":13,"SeverityText":"WARN","Timestamp":1697630400000000000}` + "\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			formatter, err := newFormatter(tc.format, nil)
+			require.NoError(t, err)
+			entry := testEntry()
+			entry.Data = logrus.Fields{callerKey: &runtime.Frame{Function: "main.main", File: "/src/app/main.go", Line: 42}}
+			got, err := formatter.Format(entry)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestProfileFormatter_levels(t *testing.T) {
+	testCases := []struct {
+		level              logrus.Level
+		gcp, ecs, otelText string
+		otelNumber         float64
+	}{
+		{logrus.PanicLevel, "ALERT", "panic", "PANIC", 24},
+		{logrus.FatalLevel, "CRITICAL", "fatal", "FATAL", 21},
+		{logrus.ErrorLevel, "ERROR", "error", "ERROR", 17},
+		{logrus.WarnLevel, "WARNING", "warn", "WARN", 13},
+		{logrus.InfoLevel, "INFO", "info", "INFO", 9},
+		{logrus.DebugLevel, "DEBUG", "debug", "DEBUG", 5},
+		{logrus.TraceLevel, "DEBUG", "trace", "TRACE", 1},
+	}
+	format := func(format Format, level logrus.Level) map[string]interface{} {
+		formatter, err := newFormatter(format, nil)
+		require.NoError(t, err)
+		entry := testEntry()
+		entry.Level = level
+		got, err := formatter.Format(entry)
+		require.NoError(t, err)
+		record := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(got, &record))
+		return record
+	}
+	for _, tc := range testCases {
+		t.Run(tc.level.String(), func(t *testing.T) {
+			assert.Equal(t, tc.gcp, format(GCPFormat, tc.level)["severity"])
+			assert.Equal(t, tc.ecs, format(ECSFormat, tc.level)["log.level"])
+			otel := format(OTelFormat, tc.level)
+			assert.Equal(t, tc.otelText, otel["SeverityText"])
+			assert.Equal(t, tc.otelNumber, otel["SeverityNumber"])
+		})
+	}
+}
+
+func TestProfileFormatter_reservedKeys(t *testing.T) {
+	formatter, err := newFormatter(GCPFormat, nil)
+	require.NoError(t, err)
+	entry := testEntry()
+	entry.Caller = nil
+	entry.Data = logrus.Fields{"severity": "high", "message": "clash"}
+	got, err := formatter.Format(entry)
+	require.NoError(t, err)
+	assert.Equal(t, `{"fields.message":"clash","fields.severity":"high","message":"hello world","severity":"WARNING","time":"2023-10-18T12:00:00Z"}`+"\n", string(got))
+}
+
+func TestSetFormat_profiles(t *testing.T) {
+	defer func() {
+		_ = SetFormat(TextFormat)
+	}()
+
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	require.NoError(t, SetFormat(GCPFormat))
+	Warnf("low disk")
+	require.NoError(t, SetFormat(OTelFormat))
+	Named("controller").WithFields(Fields{"pod": "default/web-0"}).Errorf("sync failed")
+	SetOutput(os.Stderr)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	gcp := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(lines[0], &gcp))
+	assert.Equal(t, "WARNING", gcp["severity"])
+	assert.Equal(t, "low disk", gcp["message"])
+	assert.Equal(t, "json_profiles_test.go", gcp["logging.googleapis.com/sourceLocation"].(map[string]interface{})["file"])
+
+	otel := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(lines[1], &otel))
+	assert.Equal(t, "ERROR", otel["SeverityText"])
+	assert.Equal(t, "sync failed", otel["Body"])
+	attributes := otel["Attributes"].(map[string]interface{})
+	assert.Equal(t, "json_profiles_test.go", attributes["code.filepath"])
+	assert.Equal(t, "controller", attributes["logger"])
+	assert.Equal(t, "default/web-0", attributes["pod"])
+}
+
+func TestProfileFormatter_timeLayout(t *testing.T) {
+	SetTimeLayout(EpochMillis)
+	SetTimeLocation(time.FixedZone("KST", 9*60*60))
+	defer SetTimeLayout(time.RFC3339)
+	defer SetTimeLocation(nil)
+
+	entry := testEntry()
+	entry.Time = time.Date(2023, 10, 18, 21, 0, 0, 500000000, time.FixedZone("KST", 9*60*60))
+	testCases := []struct {
+		format Format
+		key    string
+		want   string
+	}{
+		{GCPFormat, "time", "2023-10-18T21:00:00.5+09:00"},
+		{ECSFormat, "@timestamp", "2023-10-18T12:00:00.5Z"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			formatter, err := newFormatter(tc.format, nil)
+			require.NoError(t, err)
+			got, err := formatter.Format(entry)
+			require.NoError(t, err)
+			record := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(got, &record))
+			assert.Equal(t, tc.want, record[tc.key])
+		})
+	}
+}
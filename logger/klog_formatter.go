@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+var pid = os.Getpid()
+
+// klogFormatter renders entries the way k8s.io/klog/v2 does, with the header
+//
+//	Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+//
+// Entries with fields are rendered like klog.InfoS: the message quoted, then
+// the fields as key=value in key order, quoted when they are strings.
+type klogFormatter struct {
+	callerPrettyfier func(*runtime.Frame) (string, string)
+}
+
+func (f *klogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	o := optionsOf(entry)
+	data := entryData(entry.Data)
+	static := o.defaultsFor(data)
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	t := entry.Time
+	if location := o.time.location; location != nil {
+		t = t.In(location)
+	} else {
+		t = t.Local()
+	}
+	_, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	fmt.Fprintf(b, "%c%02d%02d %02d:%02d:%02d.%06d %7d ", klogSeverity(entry.Level), int(month), day, hour, minute, second, t.Nanosecond()/1000, pid)
+
+	fileVal := "???:1"
+	if entry.HasCaller() {
+		if frame, ok := entry.Data[callerKey].(*runtime.Frame); ok {
+			_, fileVal = o.frameCaller(frame)
+		} else {
+			_, fileVal = f.callerPrettyfier(entry.Caller)
+		}
+	}
+	b.WriteString(fileVal)
+	b.WriteString("] ")
+
+	if len(data) == 0 && (static == nil || len(static.keys) == 0) {
+		b.WriteString(entry.Message)
+	} else {
+		b.WriteString(strconv.Quote(entry.Message))
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		static.each(keys, func(key string, i int) {
+			b.WriteByte(' ')
+			b.WriteString(key)
+			if i >= 0 {
+				b.Write(static.klog[i])
+			} else {
+				appendKlogValue(b, data[key])
+			}
+		})
+	}
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+func klogSeverity(level logrus.Level) byte {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return 'F'
+	case logrus.ErrorLevel:
+		return 'E'
+	case logrus.WarnLevel:
+		return 'W'
+	}
+	return 'I'
+}
+
+// appendKlogValue writes "=value" like klog's KVFormat.
+func appendKlogValue(b *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case fmt.Stringer:
+		appendKlogString(b, true, v.String())
+	case string:
+		appendKlogString(b, true, v)
+	case error:
+		appendKlogString(b, true, v.Error())
+	case []byte:
+		fmt.Fprintf(b, "=%+q", v)
+	default:
+		appendKlogString(b, false, fmt.Sprintf("%+v", v))
+	}
+}
+
+// appendKlogString writes a single-line value quoted or as is, and a multi-line
+// value as klog does:
+//
+//	key=<
+//		line 1
+//		line 2
+//	 >
+func appendKlogString(b *bytes.Buffer, quote bool, s string) {
+	data := []byte(s)
+	index := bytes.IndexByte(data, '\n')
+	if index == -1 {
+		b.WriteByte('=')
+		if quote {
+			b.WriteString(strconv.Quote(s))
+		} else {
+			b.WriteString(s)
+		}
+		return
+	}
+	b.WriteString("=<\n")
+	for index != -1 {
+		b.WriteByte('\t')
+		b.Write(data[:index+1])
+		data = data[index+1:]
+		index = bytes.IndexByte(data, '\n')
+	}
+	if len(data) == 0 {
+		b.WriteString(" >")
+	} else {
+		b.WriteByte('\t')
+		b.Write(data)
+		b.WriteString("\n >")
+	}
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type point struct{ X, Y int }
+
+func TestKlogFormatter(t *testing.T) {
+	SetTimeLocation(time.UTC)
+	defer SetTimeLocation(nil)
+	header := fmt.Sprintf("%7d main.go:42] ", os.Getpid())
+
+	testCases := []struct {
+		level logrus.Level
+		data  logrus.Fields
+		want  string
+	}{
+		{logrus.InfoLevel, nil, "I1018 12:00:00.123456 " + header + "hello world\n"},
+		{logrus.DebugLevel, nil, "I1018 12:00:00.123456 " + header + "hello world\n"},
+		{logrus.WarnLevel, nil, "W1018 12:00:00.123456 " + header + "hello world\n"},
+		{logrus.ErrorLevel, nil, "E1018 12:00:00.123456 " + header + "hello world\n"},
+		{logrus.FatalLevel, nil, "F1018 12:00:00.123456 " + header + "hello world\n"},
+		{logrus.InfoLevel, logrus.Fields{
+			"count": 3,
+			"err":   errors.New("boom"),
+			"user":  "alice",
+			"d":     time.Second,
+			"s":     point{1, 2},
+			"b":     []byte("hi"),
+			"msg":   "clash",
+		}, "I1018 12:00:00.123456 " + header + `"hello world" b="hi" count=3 d="1s" err="boom" fields.msg="clash" s={X:1 Y:2} user="alice"` + "\n"},
+		{logrus.InfoLevel, logrus.Fields{"multi": "a\nb"}, "I1018 12:00:00.123456 " + header + "\"hello world\" multi=<\n\ta\n\tb\n >\n"},
+		{logrus.InfoLevel, logrus.Fields{"multi": "a\n"}, "I1018 12:00:00.123456 " + header + "\"hello world\" multi=<\n\ta\n >\n"},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			formatter, err := newFormatter(KlogFormat, nil)
+			require.NoError(t, err)
+			entry := testEntry()
+			entry.Time = time.Date(2023, 10, 18, 12, 0, 0, 123456789, time.UTC)
+			entry.Level = tc.level
+			entry.Data = tc.data
+			got, err := formatter.Format(entry)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestSetFormat_klog(t *testing.T) {
+	require.NoError(t, SetFormat(KlogFormat))
+	defer func() {
+		_ = SetFormat(TextFormat)
+	}()
+
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	Infof("plain %s", "message")
+	Named("controller").WithFields(Fields{"pod": "default/web-0"}).Errorf("sync failed")
+	SetOutput(os.Stderr)
+
+	pid := fmt.Sprintf("%7d", os.Getpid())
+	assert.Regexp(t, `^I[0-9]{4} [0-9:]{8}\.[0-9]{6} `+pid+` klog_formatter_test.go:[0-9]+\] plain message
+E[0-9]{4} [0-9:]{8}\.[0-9]{6} `+pid+` klog_formatter_test.go:[0-9]+\] "sync failed" logger="controller" pod="default/web-0"
+$`, buf.String())
+}
@@ -3,56 +3,111 @@ package logger
 import (
 	"fmt"
 	"io"
+	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 var (
-	logger     *logrus.Logger
-	AllLevels  = []Level{PanicLevel, FatalLevel, ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel}
-	callerSkip = 10 // 10 for prod(default), maybe 9 for goroutine or test code
+	logger    *logrus.Logger
+	tap       *logrus.Logger // feeds sinks with entries below the main level
+	errLogger *logrus.Logger // severe entries in split output mode
+	discard   *logrus.Logger // entries nobody wants
+	AllLevels = []Level{PanicLevel, FatalLevel, ErrorLevel, WarnLevel, InfoLevel, DebugLevel, TraceLevel}
 )
 
 func init() {
+	tap = logrus.New()
+	tap.SetReportCaller(true)
+	tap.SetOutput(io.Discard)
+	tap.SetFormatter(nopFormatter{})
+	tap.SetLevel(logrus.TraceLevel)
+	tap.AddHook(dispatcher{})
+
+	errLogger = logrus.New()
+	errLogger.SetReportCaller(true)
+	errLogger.SetLevel(logrus.TraceLevel)
+	errLogger.SetFormatter(mainErrOut)
+	errLogger.SetOutput(mainErrOut)
+	errLogger.AddHook(dispatcher{})
+
+	discard = logrus.New()
+	discard.SetOutput(io.Discard)
+	discard.SetFormatter(nopFormatter{})
+	discard.SetLevel(logrus.PanicLevel)
+
+	// levels are checked by route, so the logrus loggers accept everything
 	logger = logrus.New()
 	logger.SetReportCaller(true)
+	logger.SetLevel(logrus.TraceLevel)
+	logger.SetFormatter(mainOut)
+	logger.SetOutput(mainOut)
+	logger.AddHook(dispatcher{})
+	currentOptions.Store(&options{
+		levelsFor:      map[string]Level{},
+		callerSkip:     10,
+		format:         TextFormat,
+		output:         os.Stderr,
+		splitThreshold: -1,
+		time:           timeOptions{layout: time.RFC3339},
+		defaults:       &staticFields{},
+		tapLevel:       -1,
+	})
 	SetLevel(InfoLevel)
 	SetFullpath(false)
+	configureFromEnvAtInit()
 }
 
 // setters & getters...
 
 func SetOutput(output io.Writer) {
-	logger.SetOutput(output)
+	_ = updateOptions(func(o *options) {
+		o.output = output
+		o.errOutput = nil
+		o.splitThreshold = -1
+	})
 }
 
 func SetLevel(level Level) {
-	logger.SetLevel(logrus.Level(level))
+	_ = updateOptions(func(o *options) {
+		o.level = level
+	})
 }
 
 func GetLevel() Level {
-	return Level(logger.GetLevel())
+	return loadOptions().level
+}
+
+func SetFullpath(enabled bool) {
+	_ = updateOptions(func(o *options) {
+		o.fullpath = enabled
+	})
 }
 
-func SetFullpath(fullpath bool) {
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp:    true,
-		CallerPrettyfier: getCallerPrettyfier(fullpath),
+// SetFormat sets the format of the main output.
+func SetFormat(format Format) error {
+	return updateOptions(func(o *options) {
+		o.format = format
 	})
 }
 
 func SetCallerSkip(skip int) {
-	callerSkip = skip
+	_ = updateOptions(func(o *options) {
+		o.callerSkip = skip
+	})
 }
 
 func getCallerPrettyfier(fullpath bool) func(f *runtime.Frame) (string, string) {
 	// https://github.com/sirupsen/logrus/blob/v1.9.0/example_custom_caller_test.go
 	// https://github.com/kubernetes/klog/blob/v2.90.1/klog.go#L644
+	// mainOutput.Format calls the formatter, one frame deeper than logrus
+	// would, which the skips leave uncounted.
 	if fullpath {
 		return func(_ *runtime.Frame) (string, string) {
-			_, file, line, ok := runtime.Caller(9)
+			_, file, line, ok := runtime.Caller(9 + 1)
 			if !ok {
 				file = "???"
 				line = 1
@@ -61,7 +116,11 @@ func getCallerPrettyfier(fullpath bool) func(f *runtime.Frame) (string, string)
 		}
 	}
 	return func(_ *runtime.Frame) (string, string) {
-		_, file, line, ok := runtime.Caller(callerSkip)
+		skip := loadOptions().callerSkip
+		if skip > 1 {
+			skip++
+		}
+		_, file, line, ok := runtime.Caller(skip)
 		if !ok {
 			file = "???"
 			line = 1
@@ -77,21 +136,21 @@ func getCallerPrettyfier(fullpath bool) func(f *runtime.Frame) (string, string)
 // log functions...
 
 func Debugf(format string, args ...interface{}) {
-	logger.Debugf(format, args...)
+	routef(DebugLevel, format).Debugf(format, args...)
 }
 
 func Infof(format string, args ...interface{}) {
-	logger.Infof(format, args...)
+	routef(InfoLevel, format).Infof(format, args...)
 }
 
 func Warnf(format string, args ...interface{}) {
-	logger.Warnf(format, args...)
+	routef(WarnLevel, format).Warnf(format, args...)
 }
 
 func Errorf(format string, args ...interface{}) {
-	logger.Errorf(format, args...)
+	routef(ErrorLevel, format).Errorf(format, args...)
 }
 
 func Fatalf(format string, args ...interface{}) {
-	logger.Fatalf(format, args...)
+	routef(FatalLevel, format).Fatalf(format, args...)
 }
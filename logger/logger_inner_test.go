@@ -5,9 +5,9 @@ import (
 	"os"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/kuoss/common/tester"
-	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,11 +22,11 @@ func init() {
 func TestInit(t *testing.T) {
 	assert.NotEmpty(t, logger)
 
-	assert.NotEmpty(t, logger.Formatter)
-	assert.True(t, logger.Formatter.(*logrus.TextFormatter).FullTimestamp)
-	assert.NotEmpty(t, logger.Formatter.(*logrus.TextFormatter).CallerPrettyfier)
+	assert.NotEmpty(t, loadOptions().formatter)
+	assert.Equal(t, time.RFC3339, loadOptions().time.layout)
+	assert.NotEmpty(t, loadOptions().formatter.(*textFormatter).callerPrettyfier)
 
-	funcname, filename := logger.Formatter.(*logrus.TextFormatter).CallerPrettyfier(dummyFrame)
+	funcname, filename := loadOptions().formatter.(*textFormatter).callerPrettyfier(dummyFrame)
 	assert.Equal(t, "", funcname)
 	assert.Equal(t, "???:1", filename)
 }
@@ -0,0 +1,17 @@
+module github.com/kuoss/common/logger/logpflag
+
+go 1.20
+
+require (
+	github.com/kuoss/common v0.1.0
+	github.com/spf13/pflag v1.0.5
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.9.0 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,19 @@
+// Package logpflag registers the flags of logger.RegisterFlags on a pflag.FlagSet.
+package logpflag
+
+import (
+	"flag"
+
+	"github.com/kuoss/common/logger"
+	"github.com/spf13/pflag"
+)
+
+// RegisterFlags adds --log-level, --log-format, --log-file, --log-output,
+// --log-split and --log-caller-fullpath to fs. Call Apply on the result once
+// fs has been parsed.
+func RegisterFlags(fs *pflag.FlagSet) *logger.Flags {
+	goFlags := flag.NewFlagSet("", flag.ContinueOnError)
+	f := logger.RegisterFlags(goFlags)
+	fs.AddGoFlagSet(goFlags)
+	return f
+}
@@ -0,0 +1,37 @@
+package logpflag
+
+import (
+	"io"
+	"testing"
+
+	"github.com/kuoss/common/logger"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := RegisterFlags(fs)
+
+	require.NoError(t, fs.Parse([]string{"--log-level", "warn", "--log-format=json", "--log-caller-fullpath"}))
+	assert.Equal(t, &logger.Flags{Level: logger.WarnLevel, Format: logger.JSONFormat, CallerFullpath: true}, f)
+
+	require.NoError(t, f.Apply())
+	assert.Equal(t, logger.WarnLevel, logger.GetLevel())
+
+	require.NoError(t, fs.Parse([]string{"--log-output", "stdout", "--log-file", "app.log"}))
+	assert.EqualError(t, f.Apply(), "--log-file and --log-output are mutually exclusive")
+
+	assert.EqualError(t, fs.Parse([]string{"--log-level=loud"}), `invalid argument "loud" for "--log-level" flag: not a valid Level: "loud" (want one of panic, fatal, error, warning, info, debug, trace)`)
+}
+
+func TestRegisterFlags_usage(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	_ = RegisterFlags(fs)
+	for _, name := range []string{"log-level", "log-format", "log-file", "log-output", "log-split", "log-caller-fullpath"} {
+		assert.NotNil(t, fs.Lookup(name), name)
+	}
+	assert.Equal(t, "true", fs.Lookup("log-caller-fullpath").NoOptDefVal)
+}
@@ -0,0 +1,89 @@
+// Package logrsink provides a logr.LogSink backed by package logger, for
+// controller-runtime, klog and other users of github.com/go-logr/logr.
+package logrsink
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/kuoss/common/logger"
+)
+
+// New returns a logr.Logger writing to package logger.
+func New() logr.Logger {
+	return logr.New(NewSink(logger.Named("")))
+}
+
+// NewSink returns a logr.LogSink writing to l.
+//
+// V(0) maps to InfoLevel, V(1) to DebugLevel and V(2) and above to TraceLevel.
+// Names are joined with dots as with logger.Named, so SetLevelFor applies to them.
+func NewSink(l *logger.Logger) logr.LogSink {
+	return &sink{logger: l}
+}
+
+type sink struct {
+	logger    *logger.Logger
+	callDepth int
+}
+
+var _ logr.CallDepthLogSink = &sink{}
+
+func (s *sink) Init(info logr.RuntimeInfo) {
+	s.callDepth = info.CallDepth
+}
+
+func (s *sink) Enabled(level int) bool {
+	return s.logger.Enabled(vLevel(level))
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.logger.WithFields(fields(keysAndValues)).LogDepth(s.callDepth+1, vLevel(level), msg)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	f := fields(keysAndValues)
+	f["error"] = err
+	s.logger.WithFields(f).LogDepth(s.callDepth+1, logger.ErrorLevel, msg)
+}
+
+func (s *sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &sink{logger: s.logger.WithFields(fields(keysAndValues)), callDepth: s.callDepth}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	return &sink{logger: s.logger.Named(name), callDepth: s.callDepth}
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	return &sink{logger: s.logger, callDepth: s.callDepth + depth}
+}
+
+func vLevel(level int) logger.Level {
+	switch {
+	case level <= 0:
+		return logger.InfoLevel
+	case level == 1:
+		return logger.DebugLevel
+	}
+	return logger.TraceLevel
+}
+
+func fields(keysAndValues []interface{}) logger.Fields {
+	f := make(logger.Fields, len(keysAndValues)/2+1)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		var value interface{} = "<no-value>"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		if m, ok := value.(logr.Marshaler); ok {
+			value = m.MarshalLog()
+		}
+		f[key] = value
+	}
+	return f
+}
@@ -0,0 +1,99 @@
+package logrsink
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/kuoss/common/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func captureOutput(f func()) string {
+	buf := &bytes.Buffer{}
+	logger.SetOutput(buf)
+	f()
+	logger.SetOutput(os.Stderr)
+	return buf.String()
+}
+
+type secret string
+
+func (secret) MarshalLog() interface{} {
+	return "***"
+}
+
+func TestInfo(t *testing.T) {
+	log := New().WithName("controller").WithValues("kind", "Pod")
+	output := captureOutput(func() {
+		log.Info("reconciled", "name", "web-0", "password", secret("hunter2"), "dangling")
+	})
+	assert.Regexp(t, `^time="[^"]+" level=info msg=reconciled file="logrsink_test.go:[0-9]+" dangling="<no-value>" kind=Pod logger=controller name=web-0 password="\*\*\*"\n$`, output)
+}
+
+func TestError(t *testing.T) {
+	output := captureOutput(func() {
+		New().Error(errors.New("boom"), "failed", 42, "answer")
+	})
+	assert.Regexp(t, `level=error msg=failed file="logrsink_test.go:[0-9]+" 42=answer error=boom\n$`, output)
+}
+
+func TestV(t *testing.T) {
+	logger.SetLevel(logger.DebugLevel)
+	defer logger.SetLevel(logger.InfoLevel)
+
+	log := New()
+	assert.True(t, log.V(0).Enabled())
+	assert.True(t, log.V(1).Enabled())
+	assert.False(t, log.V(2).Enabled())
+
+	output := captureOutput(func() {
+		log.V(1).Info("debug")
+		log.V(2).Info("trace")
+	})
+	assert.Contains(t, output, "level=debug msg=debug")
+	assert.NotContains(t, output, "trace")
+}
+
+func TestSetLevelFor(t *testing.T) {
+	logger.SetLevelFor("controller.pod", logger.TraceLevel)
+	defer logger.UnsetLevelFor("controller.pod")
+
+	log := New().WithName("controller")
+	assert.False(t, log.V(2).Enabled())
+	assert.True(t, log.WithName("pod").V(2).Enabled())
+}
+
+func helper(log logr.Logger) {
+	log.WithCallDepth(1).Info("from helper")
+}
+
+func TestWithCallDepth(t *testing.T) {
+	var line int
+	output := captureOutput(func() {
+		_, _, line, _ = runtime.Caller(0)
+		helper(New()) // line+1
+	})
+	require.Equal(t, 1, strings.Count(output, "\n"))
+	assert.Contains(t, output, fmt.Sprintf(`msg="from helper" file="logrsink_test.go:%d"`, line+1))
+
+	output = captureOutput(func() {
+		_, _, line, _ = runtime.Caller(0)
+		New().WithCallDepth(0).Info("direct") // line+1
+	})
+	assert.Contains(t, output, fmt.Sprintf(`msg=direct file="logrsink_test.go:%d"`, line+1))
+}
+
+func TestVLevel(t *testing.T) {
+	assert.Equal(t, logger.InfoLevel, vLevel(-1))
+	assert.Equal(t, logger.InfoLevel, vLevel(0))
+	assert.Equal(t, logger.DebugLevel, vLevel(1))
+	assert.Equal(t, logger.TraceLevel, vLevel(2))
+	assert.Equal(t, logger.TraceLevel, vLevel(10))
+}
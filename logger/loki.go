@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LokiProtobuf = "protobuf" // snappy-compressed protobuf
+	LokiJSON     = "json"
+)
+
+// LokiOptions configures a LokiExporter.
+type LokiOptions struct {
+	URL      string // the push endpoint, such as http://localhost:3100/loki/api/v1/push
+	Protocol string // LokiProtobuf (the default) or LokiJSON
+	// Labels are added to every stream, along with the default fields set by
+	// SetDefaultFields.
+	Labels map[string]string
+	// LabelFields are entry fields turned into labels, such as "logger". The
+	// "level" field, unless the entry has one, is the level of the entry.
+	LabelFields []string
+	TenantID    string            // sent as X-Scope-OrgID, for multi-tenant Loki
+	Headers     map[string]string // added to every request, for authentication
+	Client      *http.Client      // with a 10s timeout when nil
+	Batch       BatchOptions
+}
+
+// LokiExporter pushes entries to Loki in batches sent in the background, each
+// entry to the stream of its labels. Use it as the Writer of a Sink: the lines
+// are the entries rendered in the Format of the sink.
+type LokiExporter struct {
+	opts    LokiOptions
+	batcher *batcher
+}
+
+func NewLokiExporter(opts LokiOptions) (*LokiExporter, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("missing Loki URL")
+	}
+	switch opts.Protocol {
+	case "":
+		opts.Protocol = LokiProtobuf
+	case LokiProtobuf, LokiJSON:
+	default:
+		return nil, fmt.Errorf("unknown Loki protocol: %q", opts.Protocol)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.TenantID != "" {
+		headers := map[string]string{"X-Scope-OrgID": opts.TenantID}
+		for k, v := range opts.Headers {
+			headers[k] = v
+		}
+		opts.Headers = headers
+	}
+	x := &LokiExporter{opts: opts}
+	x.batcher = newBatcher("Loki "+opts.URL, opts.Batch, x.send)
+	return x, nil
+}
+
+// Write pushes p as the line of an info entry.
+func (x *LokiExporter) Write(p []byte) (int, error) {
+	if err := x.WriteEntry(&Entry{Time: time.Now(), Level: InfoLevel, Message: strings.TrimSuffix(string(p), "\n")}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements EntryWriter, queueing entry for the next push.
+func (x *LokiExporter) WriteEntry(entry *Entry, p []byte) error {
+	return x.batcher.add(entry, p)
+}
+
+// Flush pushes the entries queued so far.
+func (x *LokiExporter) Flush(ctx context.Context) error {
+	return x.batcher.flush(ctx)
+}
+
+// Close pushes the queued entries, waiting for up to 5s, and stops the exporter.
+func (x *LokiExporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	return x.batcher.close(ctx)
+}
+
+// lokiStream is a stream of the push request, with its entries in time order.
+type lokiStream struct {
+	labels  map[string]string
+	key     string // the labels in the selector syntax, {a="b", c="d"}
+	entries []lokiEntry
+}
+
+type lokiEntry struct {
+	time time.Time
+	line string
+}
+
+func (x *LokiExporter) send(ctx context.Context, batch []batchItem) error {
+	static := loadOptions().defaults.fields
+	byKey := map[string]*lokiStream{}
+	var streams []*lokiStream
+	for _, item := range batch {
+		labels := x.labels(static, item.entry)
+		key := lokiLabelString(labels)
+		s := byKey[key]
+		if s == nil {
+			s = &lokiStream{labels: labels, key: key}
+			byKey[key] = s
+			streams = append(streams, s)
+		}
+		s.entries = append(s.entries, lokiEntry{time: item.entry.Time, line: strings.TrimSuffix(string(item.p), "\n")})
+	}
+	for _, s := range streams {
+		sort.SliceStable(s.entries, func(i, j int) bool {
+			return s.entries[i].time.Before(s.entries[j].time)
+		})
+	}
+
+	if x.opts.Protocol == LokiJSON {
+		body, err := json.Marshal(lokiJSONRequest(streams))
+		if err != nil {
+			return &permanentError{err: err}
+		}
+		_, err = postBatch(ctx, x.opts.Client, x.opts.URL, "application/json", x.opts.Headers, body)
+		return err
+	}
+	body := snappyEncode(appendLokiProto(nil, streams))
+	_, err := postBatch(ctx, x.opts.Client, x.opts.URL, "application/x-protobuf", x.opts.Headers, body)
+	return err
+}
+
+func (x *LokiExporter) labels(static Fields, entry *Entry) map[string]string {
+	labels := make(map[string]string, len(x.opts.Labels)+len(static)+len(x.opts.LabelFields))
+	for k, v := range static {
+		labels[lokiLabelName(k)] = fmt.Sprint(v)
+	}
+	for k, v := range x.opts.Labels {
+		labels[lokiLabelName(k)] = v
+	}
+	for _, field := range x.opts.LabelFields {
+		if v, ok := entry.Fields[field]; ok {
+			labels[lokiLabelName(field)] = fmt.Sprint(v)
+		} else if field == "level" {
+			labels["level"] = entry.Level.String()
+		}
+	}
+	return labels
+}
+
+// lokiLabelName turns name into a valid label name, replacing invalid characters with _.
+func lokiLabelName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func lokiLabelString(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// lokiJSONRequest is the JSON push request:
+//
+//	{"streams": [{"stream": {"label": "value"}, "values": [["<unix ns>", "<line>"]]}]}
+func lokiJSONRequest(streams []*lokiStream) interface{} {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	req := struct {
+		Streams []stream `json:"streams"`
+	}{Streams: make([]stream, len(streams))}
+	for i, s := range streams {
+		values := make([][2]string, len(s.entries))
+		for j, e := range s.entries {
+			values[j] = [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line}
+		}
+		req.Streams[i] = stream{Stream: s.labels, Values: values}
+	}
+	return req
+}
+
+// appendLokiProto appends the logproto.PushRequest of streams:
+//
+//	PushRequest   { repeated StreamAdapter streams = 1; }
+//	StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	EntryAdapter  { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func appendLokiProto(b []byte, streams []*lokiStream) []byte {
+	for _, s := range streams {
+		b = appendProtoMessage(b, 1, func(b []byte) []byte {
+			b = appendProtoString(b, 1, s.key)
+			for _, e := range s.entries {
+				b = appendProtoMessage(b, 2, func(b []byte) []byte {
+					b = appendProtoMessage(b, 1, func(b []byte) []byte {
+						b = appendProtoVarint(b, 1, uint64(e.time.Unix()))
+						if nanos := e.time.Nanosecond(); nanos != 0 {
+							b = appendProtoVarint(b, 2, uint64(nanos))
+						}
+						return b
+					})
+					return appendProtoString(b, 2, e.line)
+				})
+			}
+			return b
+		})
+	}
+	return b
+}
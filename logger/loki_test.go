@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+// parseProto splits a protobuf message into its varint and length-delimited fields.
+func parseProto(t *testing.T, b []byte) []protoField {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		require.Positive(t, n)
+		b = b[n:]
+		f := protoField{num: int(tag >> 3)}
+		switch tag & 7 {
+		case wireVarint:
+			f.varint, n = binary.Uvarint(b)
+			require.Positive(t, n)
+			b = b[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			require.Positive(t, n)
+			f.bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+type lokiPush struct {
+	header http.Header
+	body   []byte
+}
+
+// lokiStandIn is a stand-in Loki passing the pushes it gets to pushes.
+func lokiStandIn(t *testing.T) (*httptest.Server, chan lokiPush) {
+	pushes := make(chan lokiPush, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		pushes <- lokiPush{header: r.Header, body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, pushes
+}
+
+func TestLokiExporter_json(t *testing.T) {
+	srv, pushes := lokiStandIn(t)
+	require.NoError(t, SetDefaultFields(Fields{"app": "shop", "host.name": "web-1"}))
+	defer func() {
+		_ = SetDefaultFields()
+	}()
+
+	x, err := NewLokiExporter(LokiOptions{
+		URL:         srv.URL + "/loki/api/v1/push",
+		Protocol:    LokiJSON,
+		Labels:      map[string]string{"env": "prod"},
+		LabelFields: []string{"logger", "level"},
+		TenantID:    "team-a",
+	})
+	require.NoError(t, err)
+	remove, err := AddSink(Sink{Writer: x, MinLevel: InfoLevel, Format: JSONFormat})
+	require.NoError(t, err)
+
+	Named("api").Infof("first")
+	Named("db").Infof("second")
+	Named("api").Warnf("third")
+	Named("api").Infof("fourth")
+	remove()
+	require.NoError(t, x.Close())
+
+	push := <-pushes
+	assert.Equal(t, "application/json", push.header.Get("Content-Type"))
+	assert.Equal(t, "team-a", push.header.Get("X-Scope-OrgID"))
+	var req struct {
+		Streams []struct {
+			Stream map[string]string
+			Values [][2]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(push.body, &req))
+	require.Len(t, req.Streams, 3)
+	messages := func(values [][2]string) []string {
+		var messages []string
+		for _, v := range values {
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(v[1]), &line))
+			messages = append(messages, line["msg"].(string))
+		}
+		return messages
+	}
+	assert.Equal(t, map[string]string{"app": "shop", "host_name": "web-1", "env": "prod", "logger": "api", "level": "info"}, req.Streams[0].Stream)
+	assert.Equal(t, []string{"first", "fourth"}, messages(req.Streams[0].Values))
+	assert.Equal(t, map[string]string{"app": "shop", "host_name": "web-1", "env": "prod", "logger": "db", "level": "info"}, req.Streams[1].Stream)
+	assert.Equal(t, []string{"second"}, messages(req.Streams[1].Values))
+	assert.Equal(t, map[string]string{"app": "shop", "host_name": "web-1", "env": "prod", "logger": "api", "level": "warning"}, req.Streams[2].Stream)
+	assert.Equal(t, []string{"third"}, messages(req.Streams[2].Values))
+}
+
+func TestLokiExporter_protobuf(t *testing.T) {
+	srv, pushes := lokiStandIn(t)
+	x, err := NewLokiExporter(LokiOptions{URL: srv.URL + "/loki/api/v1/push", Labels: map[string]string{"job": "test", "quote": `a"b`}})
+	require.NoError(t, err)
+	t0 := time.Date(2023, 10, 18, 12, 0, 0, 500, time.UTC)
+	// out of order within the stream
+	require.NoError(t, x.WriteEntry(&Entry{Time: t0.Add(time.Second), Level: InfoLevel}, []byte("later\n")))
+	require.NoError(t, x.WriteEntry(&Entry{Time: t0, Level: InfoLevel}, []byte("earlier\n")))
+	require.NoError(t, x.Close())
+
+	push := <-pushes
+	assert.Equal(t, "application/x-protobuf", push.header.Get("Content-Type"))
+	body, err := snappyDecode(push.body)
+	require.NoError(t, err)
+
+	streams := parseProto(t, body)
+	require.Len(t, streams, 1)
+	stream := parseProto(t, streams[0].bytes)
+	require.Len(t, stream, 3)
+	assert.Equal(t, 1, stream[0].num)
+	assert.Equal(t, `{job="test", quote="a\"b"}`, string(stream[0].bytes))
+	for i, want := range []struct {
+		seconds, nanos uint64
+		line           string
+	}{
+		{uint64(t0.Unix()), 500, "earlier"},
+		{uint64(t0.Unix()) + 1, 500, "later"},
+	} {
+		assert.Equal(t, 2, stream[i+1].num)
+		entry := parseProto(t, stream[i+1].bytes)
+		require.Len(t, entry, 2)
+		timestamp := parseProto(t, entry[0].bytes)
+		assert.Equal(t, []protoField{{num: 1, varint: want.seconds}, {num: 2, varint: want.nanos}}, timestamp)
+		assert.Equal(t, 2, entry[1].num)
+		assert.Equal(t, want.line, string(entry[1].bytes))
+	}
+}
+
+func TestNewLokiExporter_invalid(t *testing.T) {
+	_, err := NewLokiExporter(LokiOptions{})
+	assert.EqualError(t, err, "missing Loki URL")
+	_, err = NewLokiExporter(LokiOptions{URL: "http://loki", Protocol: "grpc"})
+	assert.EqualError(t, err, `unknown Loki protocol: "grpc"`)
+}
+
+func TestLokiLabelName(t *testing.T) {
+	assert.Equal(t, "service_name", lokiLabelName("service.name"))
+	assert.Equal(t, "_st", lokiLabelName("1st"))
+	assert.Equal(t, "a1_B", lokiLabelName("a1_B"))
+}
@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"context"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Logger is a named logger for a component. Its entries carry the name in
+// the "logger" field, and SetLevelFor can tune its level.
+type Logger struct {
+	name   string
+	fields Fields
+	ctx    context.Context
+}
+
+// Named returns a logger with the given name.
+func Named(name string) *Logger {
+	return &Logger{name: name}
+}
+
+// Named returns a child logger whose name is joined to l's name with a dot.
+func (l *Logger) Named(name string) *Logger {
+	if l.name == "" {
+		return &Logger{name: name, fields: l.fields, ctx: l.ctx}
+	}
+	if name == "" {
+		return l
+	}
+	return &Logger{name: l.name + "." + name, fields: l.fields, ctx: l.ctx}
+}
+
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// WithFields returns a logger adding the given fields to every entry.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Logger{name: l.name, fields: merged, ctx: l.ctx}
+}
+
+// WithContext returns a logger whose entries carry ctx, for sinks that read
+// from it, such as the trace and span IDs picked up by OTLPExporter.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	return &Logger{name: l.name, fields: l.fields, ctx: ctx}
+}
+
+// Enabled reports whether an entry at level would be written anywhere.
+func (l *Logger) Enabled(level Level) bool {
+	o := loadOptions()
+	return routeAt(o, level, l.level(o)) != discard
+}
+
+// SetLevelFor sets the level of the named logger and its descendants,
+// overriding SetLevel. The longest matching name wins.
+func SetLevelFor(name string, level Level) {
+	updateLevelsFor(func(levels map[string]Level) {
+		levels[name] = level
+	})
+}
+
+// UnsetLevelFor removes the level set with SetLevelFor.
+func UnsetLevelFor(name string) {
+	updateLevelsFor(func(levels map[string]Level) {
+		delete(levels, name)
+	})
+}
+
+func updateLevelsFor(update func(levels map[string]Level)) {
+	_ = updateOptions(func(o *options) {
+		levels := make(map[string]Level, len(o.levelsFor))
+		for k, v := range o.levelsFor {
+			levels[k] = v
+		}
+		update(levels)
+		o.levelsFor = levels
+	})
+}
+
+// GetLevel returns the level of the logger: the one set for its name or the
+// nearest ancestor, or else the global level.
+func (l *Logger) GetLevel() Level {
+	return l.level(loadOptions())
+}
+
+func (l *Logger) level(o *options) Level {
+	if levels := o.levelsFor; len(levels) > 0 {
+		for name := l.name; name != ""; {
+			if level, ok := levels[name]; ok {
+				return level
+			}
+			dot := strings.LastIndex(name, ".")
+			if dot < 0 {
+				break
+			}
+			name = name[:dot]
+		}
+	}
+	return o.level
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(DebugLevel, format, args...)
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(InfoLevel, format, args...)
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(WarnLevel, format, args...)
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(ErrorLevel, format, args...)
+}
+
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.logf(FatalLevel, format, args...)
+}
+
+// LogDepth logs msg at level, attributing it to the caller depth frames above
+// the caller of LogDepth, for adapters that add frames of their own.
+// Like Fatalf, it exits at FatalLevel.
+func (l *Logger) LogDepth(depth int, level Level, msg string) {
+	o := loadOptions()
+	target := routeAt(o, level, l.level(o))
+	if target != discard && sample(o, level, msg) {
+		l.entry(target, resolveCaller(depth+1)).Log(logrus.Level(level), msg)
+	}
+	if level <= FatalLevel {
+		target.Exit(1)
+	}
+}
+
+func (l *Logger) logf(level Level, format string, args ...interface{}) {
+	o := loadOptions()
+	target := routeAt(o, level, l.level(o))
+	if target != discard && sample(o, level, format) {
+		l.entry(target, resolveCaller(2)).Logf(logrus.Level(level), format, args...)
+	}
+	if level <= FatalLevel {
+		target.Exit(1)
+	}
+}
+
+// entry returns an entry of target carrying the logger name and fields, and a resolved caller.
+func (l *Logger) entry(target *logrus.Logger, caller *runtime.Frame) *logrus.Entry {
+	fields := make(logrus.Fields, len(l.fields)+2)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[callerKey] = caller
+	if l.name != "" {
+		fields["logger"] = l.name
+	}
+	entry := target.WithFields(fields)
+	if l.ctx != nil {
+		entry = entry.WithContext(l.ctx)
+	}
+	return entry
+}
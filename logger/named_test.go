@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kuoss/common/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamed(t *testing.T) {
+	testCases := []struct {
+		logger *Logger
+		want   string
+	}{
+		{Named("storage"), "storage"},
+		{Named("storage").Named("s3"), "storage.s3"},
+		{Named("storage").Named(""), "storage"},
+		{Named("").Named("s3"), "s3"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.logger.Name())
+		})
+	}
+}
+
+func TestLogger_Infof(t *testing.T) {
+	log := Named("storage").Named("s3")
+	output := captureOutput(func() {
+		log.Infof("hello=%s", "world")
+	})
+	assert.Regexp(t, `^time="[^"]+" level=info msg="hello=world" file="named_test.go:[0-9]+" logger=storage.s3\n$`, output)
+}
+
+func TestLogger_sink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	remove, err := AddSink(Sink{Writer: buf, MinLevel: DebugLevel, Format: JSONFormat})
+	require.NoError(t, err)
+	_ = captureOutput(func() {
+		Named("storage").Debugf("hello")
+	})
+	remove()
+	assert.Regexp(t, `^\{"file":"named_test.go:[0-9]+","level":"debug","logger":"storage","msg":"hello","time":"[^"]+"\}\n$`, buf.String())
+}
+
+func TestSetLevelFor(t *testing.T) {
+	SetLevelFor("storage", DebugLevel)
+	SetLevelFor("storage.s3", WarnLevel)
+	defer UnsetLevelFor("storage")
+	defer UnsetLevelFor("storage.s3")
+
+	assert.Equal(t, DebugLevel, Named("storage").GetLevel())
+	assert.Equal(t, DebugLevel, Named("storage").Named("gcs").GetLevel())
+	assert.Equal(t, WarnLevel, Named("storage").Named("s3").Named("client").GetLevel())
+	assert.Equal(t, InfoLevel, Named("storagex").GetLevel())
+	assert.Equal(t, InfoLevel, Named("api").GetLevel())
+
+	output := captureOutput(func() {
+		Named("storage").Debugf("storage debug")
+		Named("storage.s3").Infof("s3 info")
+		Named("api").Debugf("api debug")
+		Debugf("global debug")
+	})
+	assert.Contains(t, output, "storage debug")
+	assert.NotContains(t, output, "s3 info")
+	assert.NotContains(t, output, "api debug")
+	assert.NotContains(t, output, "global debug")
+
+	UnsetLevelFor("storage")
+	assert.Equal(t, InfoLevel, Named("storage").GetLevel())
+}
+
+func TestLogger_Fatalf(t *testing.T) {
+	_, output, err := tester.RunChild(func() {
+		Named("main").Fatalf("hello=%s", "world")
+	})
+	assert.Regexp(t, `level=fatal msg="hello=world" file="named_test.go:[0-9]+" logger=main`, output)
+	assert.Error(t, err, "exit status 1")
+}
+
+func TestLogger_WithFields(t *testing.T) {
+	log := Named("api").WithFields(Fields{"request_id": "abc"}).WithFields(Fields{"user": "alice"})
+	output := captureOutput(func() {
+		log.Named("auth").Warnf("denied")
+	})
+	assert.Regexp(t, `level=warning msg=denied file="named_test.go:[0-9]+" logger=api.auth request_id=abc user=alice\n$`, output)
+}
+
+func TestLogger_Enabled(t *testing.T) {
+	log := Named("api")
+	assert.True(t, log.Enabled(InfoLevel))
+	assert.False(t, log.Enabled(DebugLevel))
+
+	remove, err := AddSink(Sink{Writer: &bytes.Buffer{}, MinLevel: DebugLevel})
+	require.NoError(t, err)
+	assert.True(t, log.Enabled(DebugLevel))
+	remove()
+
+	SetLevelFor("api", TraceLevel)
+	assert.True(t, log.Enabled(TraceLevel))
+	UnsetLevelFor("api")
+}
+
+func TestLogger_LogDepth(t *testing.T) {
+	log := Named("adapter")
+	helper := func() {
+		log.LogDepth(1, WarnLevel, "from helper")
+	}
+	output := captureOutput(func() {
+		log.LogDepth(0, InfoLevel, "direct")
+		helper()
+	})
+	lines := bytes.Split(bytes.TrimSpace([]byte(output)), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `level=info msg=direct file="named_test.go:[0-9]+" logger=adapter$`, string(lines[0]))
+	assert.Regexp(t, `level=warning msg="from helper" file="named_test.go:[0-9]+" logger=adapter$`, string(lines[1]))
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// options is an immutable snapshot of the settings read while logging.
+// Setters replace it as a whole with a single store, so they are safe to call
+// while other goroutines log, and an entry is handled with one snapshot.
+type options struct {
+	level      Level
+	levelsFor  map[string]Level // set by SetLevelFor, never modified once stored
+	callerSkip int              // 10 for prod(default), maybe 9 for goroutine or test code
+	fullpath   bool
+	format     Format
+	output     io.Writer
+	errOutput  io.Writer // in split output mode
+	// splitThreshold is the least severe level written to errOutput, or -1
+	// when split output mode is off.
+	splitThreshold int32
+	time           timeOptions
+	defaultFields  Fields
+	sinks          []*sinkState
+	recorder       *recorder
+	dump           *dumpOptions
+	sampler        *sampler
+	redactor       *redactor
+	statsByFile    bool
+
+	// derived from the settings above by updateOptions
+	formatter    logrus.Formatter
+	errFormatter logrus.Formatter // formatter unless in split output mode
+	defaults     *staticFields    // defaultFields, redacted
+	// tapLevel is the most verbose level wanted by any sink or the recorder,
+	// or -1 without either.
+	tapLevel int32
+}
+
+var (
+	optionsMu      sync.Mutex // serializes updateOptions
+	currentOptions atomic.Pointer[options]
+)
+
+func loadOptions() *options {
+	return currentOptions.Load()
+}
+
+// updateOptions stores a copy of the current options changed by update,
+// with the formatters, default fields and tap level following from it.
+// Nothing changes if those fail.
+func updateOptions(update func(o *options)) error {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	o := *currentOptions.Load()
+	update(&o)
+	prettyfier := getCallerPrettyfier(o.fullpath)
+	formatter, err := formatterFor(o.format, prettyfier, o.output)
+	if err != nil {
+		return err
+	}
+	o.formatter, o.errFormatter = formatter, formatter
+	if o.errOutput != nil {
+		o.errFormatter, _ = formatterFor(o.format, prettyfier, o.errOutput)
+	}
+	fields := o.defaultFields
+	if o.redactor != nil {
+		fields = o.redactor.redactFields(fields)
+	}
+	if o.defaults, err = newStaticFields(fields); err != nil {
+		return err
+	}
+	o.tapLevel = -1
+	for _, s := range o.sinks {
+		if int32(s.MinLevel) > o.tapLevel {
+			o.tapLevel = int32(s.MinLevel)
+		}
+	}
+	if o.recorder != nil && int32(o.recorder.level) > o.tapLevel {
+		o.tapLevel = int32(o.recorder.level)
+	}
+	currentOptions.Store(&o)
+	return nil
+}
+
+// optionsOf returns the options entry is written with.
+func optionsOf(entry *logrus.Entry) *options {
+	if o, ok := entry.Data[optionsKey].(*options); ok {
+		return o
+	}
+	return loadOptions()
+}
+
+// mainOutput is both the Formatter and the Out of the logrus loggers writing
+// the main output, so that each entry is formatted and written with the same
+// options. logrus calls Format then Write with the logger locked.
+type mainOutput struct {
+	errors bool // the error output of split output mode
+	o      *options
+}
+
+var (
+	mainOut    = &mainOutput{}
+	mainErrOut = &mainOutput{errors: true}
+)
+
+func (m *mainOutput) Format(entry *logrus.Entry) ([]byte, error) {
+	m.o = loadOptions()
+	entry.Data[optionsKey] = m.o
+	if m.errors {
+		return m.o.errFormatter.Format(entry)
+	}
+	return m.o.formatter.Format(entry)
+}
+
+func (m *mainOutput) Write(p []byte) (int, error) {
+	w := m.o.output
+	if m.errors && m.o.errOutput != nil {
+		w = m.o.errOutput
+	}
+	m.o = nil
+	return w.Write(p)
+}
+
+// drainOutputs returns once the entries being written to the main output
+// with options replaced before the call are written, by taking the logger
+// locks logrus holds while formatting and writing.
+func drainOutputs() {
+	logger.SetOutput(mainOut)
+	errLogger.SetOutput(mainErrOut)
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOptions(t *testing.T) {
+	before := loadOptions()
+	require.NoError(t, SetFormat(JSONFormat))
+	defer func() {
+		_ = SetFormat(TextFormat)
+	}()
+
+	after := loadOptions()
+	assert.NotSame(t, before, after)
+	assert.Equal(t, TextFormat, before.format)
+	assert.Equal(t, JSONFormat, after.format)
+	assert.IsType(t, &jsonFormatter{}, loadOptions().formatter)
+	assert.IsType(t, &jsonFormatter{}, loadOptions().errFormatter)
+
+	assert.EqualError(t, SetFormat("xml"), `unknown format: "xml"`)
+	assert.Same(t, after, loadOptions())
+}
+
+// TestSetters_race logs from several goroutines while others call every
+// setter; run it with -race.
+func TestSetters_race(t *testing.T) {
+	SetOutput(io.Discard)
+	defer func() {
+		resetConfig()    // level, format, fullpath, output and named levels
+		SetCallerSkip(9) // for go test
+		SetTimeLayout(time.RFC3339)
+		SetTimeLocation(nil)
+		_ = SetDefaultFields()
+		SetRecorder(0, DebugLevel)
+		SetStatsByFile(false)
+	}()
+
+	done := make(chan struct{})
+	var loggers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		loggers.Add(1)
+		go func() {
+			defer loggers.Done()
+			l := Named("race").WithFields(Fields{"k": "v"})
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				Debugf("debug %d", 1)
+				Infof("info %d", 2)
+				l.Warnf("warn %d", 3)
+				l.LogDepth(0, InfoLevel, "depth")
+				_ = Recent()
+			}
+		}()
+	}
+
+	setters := []func(i int){
+		func(i int) { SetLevel(AllLevels[i%len(AllLevels)]) },
+		func(i int) { SetFullpath(i%2 == 0) },
+		func(i int) { SetCallerSkip(9 + i%2) },
+		func(i int) { _ = SetFormat([]Format{TextFormat, JSONFormat}[i%2]) },
+		func(i int) { SetTimeLayout([]string{time.RFC3339, EpochMillis, ""}[i%3]) },
+		func(i int) { SetTimeLocation([]*time.Location{nil, time.UTC}[i%2]) },
+		func(i int) { _ = SetDefaultFields(Fields{"i": i}) },
+		func(i int) { SetLevelFor("race", AllLevels[i%len(AllLevels)]) },
+		func(i int) { SetRecorder(i%3*8, DebugLevel) },
+		func(i int) { SetStatsByFile(i%2 == 0) },
+		func(i int) {
+			remove, _ := AddSink(Sink{Writer: io.Discard, MinLevel: TraceLevel, Format: JSONFormat})
+			remove()
+		},
+		func(i int) {
+			if i%2 == 0 {
+				SetSplitOutput(io.Discard, io.Discard, WarnLevel)
+			} else {
+				SetOutput(io.Discard)
+			}
+		},
+		func(i int) {
+			cfg := DefaultConfig()
+			cfg.Sampling.Initial = i % 2
+			cfg.Redaction.Fields = []string{"k"}
+			_ = ApplyConfig(cfg)
+			SetOutput(io.Discard)
+		},
+	}
+	var wg sync.WaitGroup
+	for _, set := range setters {
+		wg.Add(1)
+		go func(set func(int)) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				set(i)
+			}
+		}(set)
+	}
+	wg.Wait()
+	close(done)
+	loggers.Wait()
+}
@@ -0,0 +1,284 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	OTLPProtobuf = "http/protobuf"
+	OTLPJSON     = "http/json"
+)
+
+const otlpScopeName = "github.com/kuoss/common/logger"
+
+// OTLPOptions configures an OTLPExporter.
+type OTLPOptions struct {
+	// Endpoint is the URL logs are posted to. When empty, it comes from
+	// OTEL_EXPORTER_OTLP_LOGS_ENDPOINT, or OTEL_EXPORTER_OTLP_ENDPOINT followed
+	// by /v1/logs, or else is http://localhost:4318/v1/logs.
+	Endpoint string
+	Protocol string            // OTLPProtobuf (the default) or OTLPJSON
+	Headers  map[string]string // added to every request, for authentication
+	// Resource holds the resource attributes. service.name defaults to
+	// unknown_service: followed by the program name, as in OpenTelemetry SDKs.
+	Resource map[string]string
+	// SpanContext returns the trace and span IDs of the span in ctx, for
+	// entries logged through Logger.WithContext. With OpenTelemetry:
+	//
+	//	func(ctx context.Context) ([16]byte, [8]byte) {
+	//		sc := trace.SpanContextFromContext(ctx)
+	//		return sc.TraceID(), sc.SpanID()
+	//	}
+	SpanContext func(ctx context.Context) (traceID [16]byte, spanID [8]byte)
+	Client      *http.Client // with a 10s timeout when nil
+	Batch       BatchOptions
+}
+
+// OTLPExporter exports entries to an OpenTelemetry collector with OTLP/HTTP,
+// in batches sent in the background. Use it as the Writer of a Sink; the
+// message is sent as the body of the log record and the fields as its
+// attributes, so the Format of the sink is not used.
+type OTLPExporter struct {
+	opts     OTLPOptions
+	resource otlpResource
+	batcher  *batcher
+}
+
+func NewOTLPExporter(opts OTLPOptions) (*OTLPExporter, error) {
+	if opts.Endpoint == "" {
+		opts.Endpoint = os.Getenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT")
+	}
+	if opts.Endpoint == "" {
+		if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+			opts.Endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/logs"
+		}
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	switch opts.Protocol {
+	case "":
+		opts.Protocol = OTLPProtobuf
+	case OTLPProtobuf, OTLPJSON:
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol: %q", opts.Protocol)
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resource := map[string]string{"service.name": "unknown_service:" + filepath.Base(os.Args[0])}
+	for k, v := range opts.Resource {
+		resource[k] = v
+	}
+	keys := make([]string, 0, len(resource))
+	for k := range resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	x := &OTLPExporter{opts: opts}
+	for _, k := range keys {
+		x.resource.Attributes = append(x.resource.Attributes, otlpKeyValue{Key: k, Value: otlpValue{resource[k]}})
+	}
+	x.batcher = newBatcher("OTLP endpoint "+opts.Endpoint, opts.Batch, x.send)
+	return x, nil
+}
+
+// Write exports p as the body of an info entry.
+func (x *OTLPExporter) Write(p []byte) (int, error) {
+	if err := x.WriteEntry(&Entry{Time: time.Now(), Level: InfoLevel, Message: strings.TrimSuffix(string(p), "\n")}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements EntryWriter, queueing entry for export.
+func (x *OTLPExporter) WriteEntry(entry *Entry, p []byte) error {
+	return x.batcher.add(entry, p)
+}
+
+// Flush exports the entries queued so far.
+func (x *OTLPExporter) Flush(ctx context.Context) error {
+	return x.batcher.flush(ctx)
+}
+
+// Close exports the queued entries, waiting for up to 5s, and stops the exporter.
+func (x *OTLPExporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	return x.batcher.close(ctx)
+}
+
+func (x *OTLPExporter) send(ctx context.Context, batch []batchItem) error {
+	records := make([]otlpLogRecord, len(batch))
+	for i, item := range batch {
+		records[i] = x.record(item.entry)
+	}
+	req := otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource:  x.resource,
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: otlpScopeName}, LogRecords: records}},
+	}}}
+	if x.opts.Protocol == OTLPJSON {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return &permanentError{err: err}
+		}
+		_, err = postBatch(ctx, x.opts.Client, x.opts.Endpoint, "application/json", x.opts.Headers, body)
+		return err
+	}
+	_, err := postBatch(ctx, x.opts.Client, x.opts.Endpoint, "application/x-protobuf", x.opts.Headers, req.appendProto(nil))
+	return err
+}
+
+func (x *OTLPExporter) record(entry *Entry) otlpLogRecord {
+	r := otlpLogRecord{
+		TimeUnixNano:         uint64(entry.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(entry.Time.UnixNano()),
+		SeverityText:         levelName(otelSeverityTexts[:], logrus.Level(entry.Level)),
+		Body:                 otlpValue{entry.Message},
+	}
+	if entry.Level <= TraceLevel {
+		r.SeverityNumber = otelSeverityNumbers[entry.Level]
+	}
+	keys := make([]string, 0, len(entry.Fields))
+	for k := range entry.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.Attributes = append(r.Attributes, otlpKeyValue{Key: k, Value: otlpValueOf(entry.Fields[k])})
+	}
+	if entry.File != "" {
+		r.Attributes = append(r.Attributes,
+			otlpKeyValue{Key: "code.filepath", Value: otlpValue{formatFile(entry.File, loadOptions().fullpath)}},
+			otlpKeyValue{Key: "code.lineno", Value: otlpValue{int64(entry.Line)}},
+		)
+	}
+	if entry.Function != "" {
+		r.Attributes = append(r.Attributes, otlpKeyValue{Key: "code.function", Value: otlpValue{entry.Function}})
+	}
+	if entry.Context != nil && x.opts.SpanContext != nil {
+		traceID, spanID := x.opts.SpanContext(entry.Context)
+		if traceID != ([16]byte{}) {
+			r.TraceID = traceID[:]
+		}
+		if spanID != ([8]byte{}) {
+			r.SpanID = spanID[:]
+		}
+	}
+	return r
+}
+
+// The OTLP messages of opentelemetry/proto/collector/logs/v1, with what
+// entries need of them.
+type (
+	otlpRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano         uint64         `json:"timeUnixNano,string"`
+		ObservedTimeUnixNano uint64         `json:"observedTimeUnixNano,string"`
+		SeverityNumber       int            `json:"severityNumber,omitempty"`
+		SeverityText         string         `json:"severityText,omitempty"`
+		Body                 otlpValue      `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+		TraceID              otlpID         `json:"traceId,omitempty"`
+		SpanID               otlpID         `json:"spanId,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+)
+
+// otlpValue is an AnyValue holding a string, bool, int64, float64 or []byte.
+type otlpValue struct {
+	v interface{}
+}
+
+func otlpValueOf(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case string, bool, int64, float64, []byte:
+		return otlpValue{v}
+	case int:
+		return otlpValue{int64(v)}
+	case int8:
+		return otlpValue{int64(v)}
+	case int16:
+		return otlpValue{int64(v)}
+	case int32:
+		return otlpValue{int64(v)}
+	case uint8:
+		return otlpValue{int64(v)}
+	case uint16:
+		return otlpValue{int64(v)}
+	case uint32:
+		return otlpValue{int64(v)}
+	case uint:
+		if v <= math.MaxInt64 {
+			return otlpValue{int64(v)}
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return otlpValue{int64(v)}
+		}
+	case float32:
+		return otlpValue{float64(v)}
+	case error:
+		return otlpValue{v.Error()}
+	case fmt.Stringer:
+		return otlpValue{v.String()}
+	}
+	return otlpValue{fmt.Sprint(v)}
+}
+
+func (v otlpValue) MarshalJSON() ([]byte, error) {
+	switch v := v.v.(type) {
+	case bool:
+		return json.Marshal(map[string]bool{"boolValue": v})
+	case int64:
+		// 64-bit integers are strings in the JSON mapping of protobuf
+		return json.Marshal(map[string]string{"intValue": strconv.FormatInt(v, 10)})
+	case float64:
+		return json.Marshal(map[string]float64{"doubleValue": v})
+	case []byte:
+		return json.Marshal(map[string][]byte{"bytesValue": v})
+	case string:
+		return json.Marshal(map[string]string{"stringValue": v})
+	}
+	return []byte("{}"), nil
+}
+
+// otlpID is a trace or span ID, hex encoded in OTLP/JSON.
+type otlpID []byte
+
+func (id otlpID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(id))
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Protobuf encoding of the OTLP messages, following the field numbers of
+// opentelemetry/proto/logs/v1/logs.proto and common/v1/common.proto.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendProtoTag(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wire))
+}
+
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	b = appendProtoTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendProtoFixed64(b []byte, field int, v uint64) []byte {
+	b = appendProtoTag(b, field, wireFixed64)
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	b = appendProtoTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendProtoString(b []byte, field int, v string) []byte {
+	b = appendProtoTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendProtoMessage appends the message written by appendMessage as field.
+func appendProtoMessage(b []byte, field int, appendMessage func([]byte) []byte) []byte {
+	return appendProtoBytes(b, field, appendMessage(nil))
+}
+
+// ExportLogsServiceRequest
+func (r *otlpRequest) appendProto(b []byte) []byte {
+	for i := range r.ResourceLogs {
+		b = appendProtoMessage(b, 1, r.ResourceLogs[i].appendProto)
+	}
+	return b
+}
+
+// ResourceLogs
+func (r *otlpResourceLogs) appendProto(b []byte) []byte {
+	b = appendProtoMessage(b, 1, r.Resource.appendProto)
+	for i := range r.ScopeLogs {
+		b = appendProtoMessage(b, 2, r.ScopeLogs[i].appendProto)
+	}
+	return b
+}
+
+// Resource
+func (r *otlpResource) appendProto(b []byte) []byte {
+	for i := range r.Attributes {
+		b = appendProtoMessage(b, 1, r.Attributes[i].appendProto)
+	}
+	return b
+}
+
+// ScopeLogs
+func (s *otlpScopeLogs) appendProto(b []byte) []byte {
+	b = appendProtoMessage(b, 1, s.Scope.appendProto)
+	for i := range s.LogRecords {
+		b = appendProtoMessage(b, 2, s.LogRecords[i].appendProto)
+	}
+	return b
+}
+
+// InstrumentationScope
+func (s *otlpScope) appendProto(b []byte) []byte {
+	return appendProtoString(b, 1, s.Name)
+}
+
+// LogRecord
+func (r *otlpLogRecord) appendProto(b []byte) []byte {
+	b = appendProtoFixed64(b, 1, r.TimeUnixNano)
+	if r.SeverityNumber != 0 {
+		b = appendProtoVarint(b, 2, uint64(r.SeverityNumber))
+	}
+	if r.SeverityText != "" {
+		b = appendProtoString(b, 3, r.SeverityText)
+	}
+	b = appendProtoMessage(b, 5, r.Body.appendProto)
+	for i := range r.Attributes {
+		b = appendProtoMessage(b, 6, r.Attributes[i].appendProto)
+	}
+	if len(r.TraceID) > 0 {
+		b = appendProtoBytes(b, 9, r.TraceID)
+	}
+	if len(r.SpanID) > 0 {
+		b = appendProtoBytes(b, 10, r.SpanID)
+	}
+	return appendProtoFixed64(b, 11, r.ObservedTimeUnixNano)
+}
+
+// KeyValue
+func (kv *otlpKeyValue) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, kv.Key)
+	return appendProtoMessage(b, 2, kv.Value.appendProto)
+}
+
+// AnyValue, whose oneof is written even when it holds a zero value.
+func (v otlpValue) appendProto(b []byte) []byte {
+	switch v := v.v.(type) {
+	case string:
+		return appendProtoString(b, 1, v)
+	case bool:
+		var n uint64
+		if v {
+			n = 1
+		}
+		return appendProtoVarint(b, 2, n)
+	case int64:
+		return appendProtoVarint(b, 3, uint64(v))
+	case float64:
+		return appendProtoFixed64(b, 4, math.Float64bits(v))
+	case []byte:
+		return appendProtoBytes(b, 7, v)
+	}
+	return b
+}
@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type otlpRequestRecorder struct {
+	header http.Header
+	body   []byte
+}
+
+// otlpCollector is a stand-in collector passing the requests it gets to requests.
+func otlpCollector(t *testing.T) (*httptest.Server, chan otlpRequestRecorder) {
+	requests := make(chan otlpRequestRecorder, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- otlpRequestRecorder{header: r.Header, body: body}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+type spanKey struct{}
+
+func testSpanContext(ctx context.Context) (traceID [16]byte, spanID [8]byte) {
+	if ids, ok := ctx.Value(spanKey{}).([]byte); ok {
+		copy(traceID[:], ids[:16])
+		copy(spanID[:], ids[16:])
+	}
+	return traceID, spanID
+}
+
+func TestOTLPExporter_protobuf(t *testing.T) {
+	srv, requests := otlpCollector(t)
+	x, err := NewOTLPExporter(OTLPOptions{
+		Endpoint:    srv.URL + "/v1/logs",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+		Resource:    map[string]string{"service.name": "app"},
+		SpanContext: testSpanContext,
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, []byte{1, 2, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 5, 0, 0, 0, 0, 0, 0})
+	require.NoError(t, x.WriteEntry(&Entry{
+		Time:    time.Unix(1697630400, 0),
+		Level:   WarnLevel,
+		Message: "hello",
+		File:    "/src/main.go",
+		Line:    42,
+		Fields:  Fields{"count": 3, "ok": true, "ratio": 0.5, "user": "alice"},
+		Context: ctx,
+	}, nil))
+	require.NoError(t, x.Close())
+
+	req := <-requests
+	assert.Equal(t, "application/x-protobuf", req.header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", req.header.Get("Authorization"))
+	// checked against go.opentelemetry.io/proto/otlp
+	assert.Equal(t, "0aeb010a170a150a0c736572766963652e6e616d6512050a0361707012cf010a200a1e6769746875622e636f6d2f6b756f73732f636f6d6d6f6e2f6c6f6767657212aa01090080e7b7da318f17100d1a045741524e2a070a0568656c6c6f320b0a05636f756e741202180332080a026f6b1202100132120a05726174696f120921000000000000e03f320f0a047573657212070a05616c696365321a0a0d636f64652e66696c657061746812090a076d61696e2e676f32110a0b636f64652e6c696e656e6f1202182a4a100102030000000000000000000000000052080405000000000000590080e7b7da318f17", hex.EncodeToString(req.body))
+}
+
+func TestOTLPExporter_json(t *testing.T) {
+	srv, requests := otlpCollector(t)
+	x, err := NewOTLPExporter(OTLPOptions{
+		Endpoint:    srv.URL + "/v1/logs",
+		Protocol:    OTLPJSON,
+		Resource:    map[string]string{"service.name": "app", "deployment.environment": "test"},
+		SpanContext: testSpanContext,
+	})
+	require.NoError(t, err)
+	remove, err := AddSink(Sink{Writer: x, MinLevel: InfoLevel})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), spanKey{}, []byte("0123456789abcdefspan-id!"))
+	Named("api").WithContext(ctx).WithFields(Fields{"user": "alice"}).Errorf("request failed")
+	Infof("no context")
+	remove()
+	require.NoError(t, x.Close())
+
+	req := <-requests
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	var body struct {
+		ResourceLogs []struct {
+			Resource  map[string]interface{}
+			ScopeLogs []struct {
+				Scope      map[string]interface{}
+				LogRecords []map[string]interface{}
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(req.body, &body))
+	require.Len(t, body.ResourceLogs, 1)
+	assert.Equal(t, map[string]interface{}{"attributes": []interface{}{
+		map[string]interface{}{"key": "deployment.environment", "value": map[string]interface{}{"stringValue": "test"}},
+		map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "app"}},
+	}}, body.ResourceLogs[0].Resource)
+	scope := body.ResourceLogs[0].ScopeLogs[0]
+	assert.Equal(t, "github.com/kuoss/common/logger", scope.Scope["name"])
+	require.Len(t, scope.LogRecords, 2)
+
+	record := scope.LogRecords[0]
+	assert.Equal(t, "ERROR", record["severityText"])
+	assert.Equal(t, 17.0, record["severityNumber"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "request failed"}, record["body"])
+	assert.Equal(t, hex.EncodeToString([]byte("0123456789abcdef")), record["traceId"])
+	assert.Equal(t, hex.EncodeToString([]byte("span-id!")), record["spanId"])
+	attributes := map[string]interface{}{}
+	for _, kv := range record["attributes"].([]interface{}) {
+		kv := kv.(map[string]interface{})
+		attributes[kv["key"].(string)] = kv["value"]
+	}
+	assert.Equal(t, map[string]interface{}{"stringValue": "api"}, attributes["logger"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "alice"}, attributes["user"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "otlp_test.go"}, attributes["code.filepath"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "github.com/kuoss/common/logger.TestOTLPExporter_json"}, attributes["code.function"])
+
+	record = scope.LogRecords[1]
+	assert.Equal(t, "INFO", record["severityText"])
+	assert.NotContains(t, record, "traceId")
+}
+
+func TestOTLPExporter_retry(t *testing.T) {
+	ResetStats()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	x, err := NewOTLPExporter(OTLPOptions{Endpoint: srv.URL, Batch: BatchOptions{Backoff: time.Millisecond}})
+	require.NoError(t, err)
+	_, err = x.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.NoError(t, x.Flush(context.Background()))
+	require.NoError(t, x.Close())
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, uint64(0), Stats().Dropped)
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	x, err := NewOTLPExporter(OTLPOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:4318/v1/logs", x.opts.Endpoint)
+	assert.Equal(t, OTLPProtobuf, x.opts.Protocol)
+	require.NoError(t, x.Close())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	x, err = NewOTLPExporter(OTLPOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector:4318/v1/logs", x.opts.Endpoint)
+	require.NoError(t, x.Close())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_LOGS_ENDPOINT", "http://logs:4318/custom")
+	x, err = NewOTLPExporter(OTLPOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://logs:4318/custom", x.opts.Endpoint)
+	require.NoError(t, x.Close())
+
+	_, err = NewOTLPExporter(OTLPOptions{Protocol: "grpc"})
+	assert.EqualError(t, err, `unknown OTLP protocol: "grpc"`)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package logger
+
+// raceEnabled is set when testing with the race detector, which makes
+// allocation counts unreliable.
+const raceEnabled = true
@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recorder keeps the most recent entries in a ring, overwriting the oldest.
+type recorder struct {
+	level Level
+	slots []atomic.Pointer[record]
+	next  atomic.Uint64
+	// dumped is the seq up to which records were dumped already.
+	dumped atomic.Uint64
+}
+
+type record struct {
+	seq   uint64
+	entry *Entry
+	// suppressed is set for entries below the main level, which were not written to the main output.
+	suppressed bool
+}
+
+type dumpOptions struct {
+	trigger  Level
+	entries  int
+	cooldown time.Duration
+	// last is the time of the last dump in Unix nanoseconds.
+	last atomic.Int64
+}
+
+// SetRecorder keeps the last size entries at level or above in memory, including
+// those below the main level, for Recent and RecentHandler. A size of 0 turns it off.
+func SetRecorder(size int, level Level) {
+	var r *recorder
+	if size > 0 {
+		r = &recorder{level: level, slots: make([]atomic.Pointer[record], size)}
+	}
+	_ = updateOptions(func(o *options) {
+		o.recorder = r
+	})
+}
+
+// Recent returns the entries kept by the recorder, oldest first.
+func Recent() []*Entry {
+	r := loadOptions().recorder
+	if r == nil {
+		return nil
+	}
+	records := r.records()
+	entries := make([]*Entry, len(records))
+	for i, rec := range records {
+		entries[i] = rec.entry
+	}
+	return entries
+}
+
+// SetRecorderDump makes an entry at trigger level or above first write out the
+// last entries recorded before it that were left out of the main output, at
+// most once per cooldown. It needs SetRecorder; an entries of 0 turns it off.
+func SetRecorderDump(trigger Level, entries int, cooldown time.Duration) {
+	var dump *dumpOptions
+	if entries > 0 {
+		dump = &dumpOptions{trigger: trigger, entries: entries, cooldown: cooldown}
+	}
+	_ = updateOptions(func(o *options) {
+		o.dump = dump
+	})
+}
+
+// dumpBefore writes the dump triggered by entry, if any, to the output of entry.
+func (r *recorder) dumpBefore(opts *dumpOptions, entry *logrus.Entry) {
+	if opts == nil || entry.Level > logrus.Level(opts.trigger) || entry.Logger == tap {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := opts.last.Load()
+	if last != 0 && now-last < int64(opts.cooldown) || !opts.last.CompareAndSwap(last, now) {
+		return
+	}
+
+	records := r.records()
+	dumped := make([]*record, 0, opts.entries)
+	for i := len(records) - 1; i >= 0 && len(dumped) < opts.entries; i-- {
+		if records[i].seq < r.dumped.Load() {
+			break
+		}
+		if records[i].suppressed {
+			dumped = append(dumped, records[i])
+		}
+	}
+	if len(records) > 0 {
+		r.dumped.Store(records[len(records)-1].seq + 1)
+	}
+	for i := len(dumped) - 1; i >= 0; i-- {
+		e := dumped[i].entry.logrusEntry()
+		e.Data[dumpKey] = true
+		entry.Logger.WithFields(e.Data).WithTime(e.Time).Log(e.Level, e.Message)
+	}
+}
+
+func (r *recorder) add(entry *Entry, suppressed bool) {
+	seq := r.next.Add(1) - 1
+	r.slots[seq%uint64(len(r.slots))].Store(&record{seq: seq, entry: entry, suppressed: suppressed})
+}
+
+// records returns the records in the ring, oldest first, leaving out those
+// overwritten or not yet stored while reading.
+func (r *recorder) records() []*record {
+	size := uint64(len(r.slots))
+	end := r.next.Load()
+	start := uint64(0)
+	if end > size {
+		start = end - size
+	}
+	records := make([]*record, 0, end-start)
+	for seq := start; seq < end; seq++ {
+		if rec := r.slots[seq%size].Load(); rec != nil && rec.seq == seq {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+// RecentHandler serves the entries kept by the recorder as text. The "level"
+// query parameter keeps entries at that level or above, and "q" keeps those
+// whose text contains it.
+func RecentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		max := TraceLevel
+		if s := r.URL.Query().Get("level"); s != "" {
+			level, err := ParseLevel(s)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			max = level
+		}
+		q := []byte(r.URL.Query().Get("q"))
+
+		formatter := &textFormatter{}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, entry := range Recent() {
+			if entry.Level > max {
+				continue
+			}
+			line, err := formatter.Format(entry.logrusEntry())
+			if err != nil || !bytes.Contains(line, q) {
+				continue
+			}
+			_, _ = w.Write(line)
+		}
+	})
+}
+
+// logrusEntry turns e back into an entry the formatters can render.
+func (e *Entry) logrusEntry() *logrus.Entry {
+	caller := &runtime.Frame{File: e.File, Line: e.Line, Function: e.Function}
+	data := make(logrus.Fields, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		data[k] = v
+	}
+	data[callerKey] = caller
+	return &logrus.Entry{
+		Logger:  logger,
+		Data:    data,
+		Time:    e.Time,
+		Level:   logrus.Level(e.Level),
+		Message: e.Message,
+		Caller:  caller,
+		Context: e.Context,
+	}
+}
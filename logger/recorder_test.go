@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kuoss/common/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func messagesOf(entries []*Entry) []string {
+	messages := make([]string, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.Message
+	}
+	return messages
+}
+
+func TestRecent(t *testing.T) {
+	SetRecorder(3, DebugLevel)
+	defer SetRecorder(0, DebugLevel)
+
+	output := captureOutput(func() {
+		Named("").LogDepth(0, TraceLevel, "trace")
+		Debugf("debug")
+		Infof("info 1")
+		Infof("info 2")
+		Errorf("error")
+	})
+
+	// the main output stays at InfoLevel
+	assert.NotContains(t, output, "debug")
+
+	entries := Recent()
+	assert.Equal(t, []string{"info 1", "info 2", "error"}, messagesOf(entries))
+	assert.Equal(t, ErrorLevel, entries[2].Level)
+	assert.Equal(t, "recorder_test.go", formatFile(entries[2].File, false))
+}
+
+func TestRecent_belowMainLevel(t *testing.T) {
+	SetRecorder(10, DebugLevel)
+	defer SetRecorder(0, DebugLevel)
+
+	_ = captureOutput(func() {
+		Debugf("debug")
+		Named("db").WithFields(Fields{"table": "users"}).Debugf("query")
+	})
+
+	entries := Recent()
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"debug", "query"}, messagesOf(entries))
+	assert.Equal(t, Fields{"logger": "db", "table": "users"}, entries[1].Fields)
+	assert.Equal(t, "recorder_test.go", formatFile(entries[1].File, false))
+}
+
+func TestRecent_off(t *testing.T) {
+	SetRecorder(0, DebugLevel)
+	assert.Nil(t, Recent())
+	assert.Equal(t, int32(-1), loadOptions().tapLevel)
+
+	SetRecorder(1, TraceLevel)
+	assert.Equal(t, int32(TraceLevel), loadOptions().tapLevel)
+	SetRecorder(0, TraceLevel)
+	assert.Equal(t, int32(-1), loadOptions().tapLevel)
+}
+
+func TestRecent_concurrent(t *testing.T) {
+	SetRecorder(64, DebugLevel)
+	defer SetRecorder(0, DebugLevel)
+
+	var wg sync.WaitGroup
+	_ = captureOutput(func() {
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					Debugf("%d-%d", i, j)
+					_ = Recent()
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+	assert.Len(t, Recent(), 64)
+}
+
+func TestRecentHandler(t *testing.T) {
+	SetRecorder(10, DebugLevel)
+	defer SetRecorder(0, DebugLevel)
+
+	_ = captureOutput(func() {
+		Debugf("connecting to db")
+		Infof("listening")
+		Warnf("slow db")
+	})
+
+	testCases := []struct {
+		query string
+		code  int
+		want  []string
+	}{
+		{"", 200, []string{"connecting to db", "listening", "slow db"}},
+		{"?level=info", 200, []string{"listening", "slow db"}},
+		{"?q=db", 200, []string{"connecting to db", "slow db"}},
+		{"?level=warn&q=db", 200, []string{"slow db"}},
+		{"?level=loud", 400, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RecentHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/logs"+tc.query, nil))
+			assert.Equal(t, tc.code, rec.Code)
+			if tc.code != 200 {
+				return
+			}
+			assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+			body := rec.Body.String()
+			assert.Equal(t, len(tc.want), strings.Count(body, "\n"), body)
+			for _, msg := range tc.want {
+				assert.Regexp(t, fmt.Sprintf(`msg="?%s"? file="recorder_test.go:[0-9]+"`, msg), body)
+			}
+		})
+	}
+}
+
+func TestSetRecorderDump(t *testing.T) {
+	SetRecorder(10, DebugLevel)
+	SetRecorderDump(ErrorLevel, 2, time.Hour)
+	defer SetRecorder(0, DebugLevel)
+	defer SetRecorderDump(ErrorLevel, 0, 0)
+	ResetStats()
+
+	output := captureOutput(func() {
+		Debugf("debug 1")
+		Debugf("debug 2")
+		Infof("info")
+		Debugf("debug 3")
+		Warnf("warn")
+		Errorf("error 1")
+		Debugf("debug 4")
+		Errorf("error 2") // within the cooldown
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	require.Len(t, lines, 6)
+	assert.Regexp(t, `level=info msg=info file="recorder_test.go:[0-9]+"$`, lines[0])
+	assert.Regexp(t, `level=warning msg=warn file="recorder_test.go:[0-9]+"$`, lines[1])
+	assert.Regexp(t, `level=debug msg="debug 2" file="recorder_test.go:[0-9]+"$`, lines[2])
+	assert.Regexp(t, `level=debug msg="debug 3" file="recorder_test.go:[0-9]+"$`, lines[3])
+	assert.Regexp(t, `level=error msg="error 1" file="recorder_test.go:[0-9]+"$`, lines[4])
+	assert.Regexp(t, `level=error msg="error 2" file="recorder_test.go:[0-9]+"$`, lines[5])
+
+	// dumped entries are not recorded again, and only counted once written
+	assert.Len(t, Recent(), 8)
+	assert.Equal(t, uint64(2), Stats().Entries[DebugLevel])
+}
+
+func TestSetRecorderDump_noRepeat(t *testing.T) {
+	SetRecorder(10, DebugLevel)
+	SetRecorderDump(ErrorLevel, 5, 0)
+	defer SetRecorder(0, DebugLevel)
+	defer SetRecorderDump(ErrorLevel, 0, 0)
+
+	output := captureOutput(func() {
+		Debugf("debug 1")
+		Errorf("error 1")
+		Errorf("error 2")
+		Debugf("debug 2")
+		Errorf("error 3")
+	})
+	assert.Equal(t, 1, strings.Count(output, "debug 1"))
+	assert.Equal(t, 1, strings.Count(output, "debug 2"))
+	assert.Less(t, strings.Index(output, "debug 2"), strings.Index(output, "error 3"))
+}
+
+func TestSetRecorderDump_fatal(t *testing.T) {
+	_, output, err := tester.RunChild(func() {
+		SetRecorder(10, DebugLevel)
+		SetRecorderDump(FatalLevel, 10, 0)
+		Debugf("context")
+		Errorf("not a trigger")
+		Fatalf("fatal")
+	})
+	assert.Error(t, err, "exit status 1")
+	assert.Regexp(t, `level=error msg="not a trigger" .*\n.*level=debug msg=context .*\n.*level=fatal msg=fatal`, output)
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// redactor hides the values of some fields, and the text matching some
+// patterns in messages and string fields.
+type redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+func newRedactor(fields, patterns []string) (*redactor, error) {
+	r := &redactor{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		r.fields[field] = true
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// redact rewrites entry in place, which logrus copies for each call.
+func (r *redactor) redact(entry *logrus.Entry) {
+	entry.Message = r.replace(entry.Message)
+	for k, v := range entry.Data {
+		entry.Data[k] = r.value(k, v)
+	}
+}
+
+// redactFields returns a copy of fields as redact leaves them.
+func (r *redactor) redactFields(fields Fields) Fields {
+	redactedFields := make(Fields, len(fields))
+	for k, v := range fields {
+		redactedFields[k] = r.value(k, v)
+	}
+	return redactedFields
+}
+
+// value returns what is left of the value v of field k. Errors and
+// fmt.Stringers become their redacted text when the patterns match it.
+func (r *redactor) value(k string, v interface{}) interface{} {
+	if r.fields[k] {
+		return redacted
+	}
+	var s string
+	switch v := v.(type) {
+	case string:
+		return r.replace(v)
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return v
+	}
+	if replaced := r.replace(s); replaced != s {
+		return replaced
+	}
+	return v
+}
+
+func (r *redactor) replace(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, redacted)
+	}
+	return s
+}
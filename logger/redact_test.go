@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	r, err := newRedactor([]string{"password"}, []string{`token=\w+`, `\d{4}-\d{4}-\d{4}-\d{4}`})
+	require.NoError(t, err)
+
+	cause := errors.New("timeout")
+	entry := &logrus.Entry{
+		Message: "login with token=abc123 and card 1234-5678-9012-3456",
+		Data: logrus.Fields{
+			"password": "hunter2",
+			"url":      "/?token=xyz",
+			"count":    3,
+			"err":      errors.New("token=abc"),
+			"cause":    cause,
+			"card":     stringer("1234-5678-9012-3456"),
+		},
+	}
+	r.redact(entry)
+	assert.Equal(t, "login with [REDACTED] and card [REDACTED]", entry.Message)
+	assert.Equal(t, logrus.Fields{
+		"password": "[REDACTED]",
+		"url":      "/?[REDACTED]",
+		"count":    3,
+		"err":      "[REDACTED]",
+		"cause":    cause, // nothing to hide
+		"card":     "[REDACTED]",
+	}, entry.Data)
+}
+
+type stringer string
+
+func (s stringer) String() string {
+	return string(s)
+}
+
+func TestNewRedactor_invalid(t *testing.T) {
+	_, err := newRedactor(nil, []string{"[a-"})
+	assert.EqualError(t, err, "error parsing regexp: missing closing ]: `[a-`")
+}
+
+func TestRedact_output(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Redaction = RedactionConfig{Fields: []string{"password"}, Patterns: []string{`secret-\w+`}}
+	require.NoError(t, ApplyConfig(cfg))
+	defer resetConfig()
+
+	output := captureOutput(func() {
+		Infof("using secret-abc")
+		Named("auth").WithFields(Fields{"password": "hunter2"}).Infof("login")
+	})
+	assert.Regexp(t, `level=info msg="using \[REDACTED\]" file="redact_test.go:[0-9]+"\n`, output)
+	assert.Regexp(t, `level=info msg=login file="redact_test.go:[0-9]+" logger=auth password="\[REDACTED\]"\n$`, output)
+}
+
+func TestRedact_defaultFields(t *testing.T) {
+	require.NoError(t, SetDefaultFields(Fields{"password": "hunter2", "dsn": "db?secret-abc"}))
+	defer func() { _ = SetDefaultFields() }()
+	cfg := DefaultConfig()
+	cfg.Redaction = RedactionConfig{Fields: []string{"password"}, Patterns: []string{`secret-\w+`}}
+	require.NoError(t, ApplyConfig(cfg))
+	defer resetConfig()
+
+	output := captureOutput(func() {
+		Infof("started")
+	})
+	assert.Regexp(t, `level=info msg=started file="redact_test.go:[0-9]+" dsn="db\?\[REDACTED\]" password="\[REDACTED\]"\n$`, output)
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+// samplerSize is the number of counters per level; messages hashing to the
+// same counter are sampled together.
+const samplerSize = 1024
+
+// sampler writes the first initial entries with the same level and message in
+// each tick, then every thereafter-th, the way zap does.
+type sampler struct {
+	initial    uint64
+	thereafter uint64
+	tick       int64
+	counters   [TraceLevel + 1][samplerSize]samplerCounter
+}
+
+type samplerCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Uint64
+}
+
+func newSampler(initial, thereafter int, tick time.Duration) *sampler {
+	return &sampler{initial: uint64(initial), thereafter: uint64(thereafter), tick: int64(tick)}
+}
+
+// sample reports whether an entry with the given level and message, or
+// format, should be written. Fatal and Panic entries always are.
+func sample(o *options, level Level, msg string) bool {
+	s := o.sampler
+	if s == nil || level <= FatalLevel || level > TraceLevel {
+		return true
+	}
+	if s.keep(level, msg, time.Now().UnixNano()) {
+		return true
+	}
+	stats.sampled.Add(1)
+	return false
+}
+
+func (s *sampler) keep(level Level, msg string, now int64) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(msg))
+	c := &s.counters[level][h.Sum32()%samplerSize]
+
+	resetAt := c.resetAt.Load()
+	if resetAt <= now && c.resetAt.CompareAndSwap(resetAt, now+s.tick) {
+		c.n.Store(1)
+		return s.initial > 0
+	}
+	n := c.n.Add(1)
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
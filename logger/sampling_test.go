@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplerKeep(t *testing.T) {
+	s := newSampler(2, 3, time.Second)
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		if s.keep(InfoLevel, "hello", 0) {
+			kept = append(kept, i)
+		}
+	}
+	// the first 2, then every 3rd
+	assert.Equal(t, []int{1, 2, 5, 8}, kept)
+
+	// other messages and levels are counted apart
+	assert.True(t, s.keep(InfoLevel, "other", 0))
+	assert.True(t, s.keep(WarnLevel, "hello", 0))
+
+	// a new tick starts over
+	assert.True(t, s.keep(InfoLevel, "hello", int64(time.Second)))
+	assert.True(t, s.keep(InfoLevel, "hello", int64(time.Second)))
+	assert.False(t, s.keep(InfoLevel, "hello", int64(time.Second)))
+}
+
+func TestSamplerKeep_noThereafter(t *testing.T) {
+	s := newSampler(1, 0, time.Second)
+	assert.True(t, s.keep(InfoLevel, "hello", 0))
+	for i := 0; i < 10; i++ {
+		assert.False(t, s.keep(InfoLevel, "hello", 0))
+	}
+}
+
+func TestSample(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Sampling = SamplingConfig{Initial: 2, Thereafter: 100, Tick: time.Hour}
+	require.NoError(t, ApplyConfig(cfg))
+	defer resetConfig()
+	ResetStats()
+
+	output := captureOutput(func() {
+		for i := 0; i < 5; i++ {
+			Infof("repeated %d", i)
+			Named("db").Warnf("slow")
+		}
+		Debugf("not written anyway")
+	})
+	assert.Equal(t, 2, strings.Count(output, "repeated"))
+	assert.Contains(t, output, `msg="repeated 1"`)
+	assert.Equal(t, 2, strings.Count(output, "slow"))
+
+	got := Stats()
+	assert.Equal(t, uint64(6), got.Sampled)
+	assert.Equal(t, uint64(2), got.Entries[InfoLevel])
+}
@@ -0,0 +1,330 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is an additional output receiving every entry at MinLevel or above
+// that passes Filter, rendered in its own Format.
+// Entries are written by a goroutine of the sink, so that a slow Writer never
+// holds up logging; fatal and panic entries are waited for.
+type Sink struct {
+	Writer   io.Writer
+	MinLevel Level
+	Format   Format
+	Filter   func(entry *Entry) bool
+	// QueueSize bounds the entries waiting for Writer, 1024 when 0. Entries
+	// arriving while the queue is full are dropped and counted in Stats.
+	QueueSize int
+}
+
+// EntryWriter is implemented by sink writers that need the entry as well as
+// its rendering p, such as SyslogWriter.
+type EntryWriter interface {
+	WriteEntry(entry *Entry, p []byte) error
+}
+
+type sinkState struct {
+	Sink
+	formatter logrus.Formatter
+	queue     chan sinkItem
+	done      chan struct{} // closed once the writer goroutine returns
+	// mu guards sending to queue against stop closing it.
+	mu      sync.RWMutex
+	stopped bool
+}
+
+// sinkItem is an entry rendered for a sink, or a flush when p is nil.
+type sinkItem struct {
+	entry   *Entry // set for EntryWriter sinks
+	p       []byte
+	written chan struct{} // closed once written, if not nil
+}
+
+// AddSink registers a sink next to the main output and returns a function removing it.
+func AddSink(sink Sink) (remove func(), err error) {
+	s, err := newSinkState(sink)
+	if err != nil {
+		return nil, err
+	}
+	_ = updateOptions(func(o *options) {
+		o.sinks = append(o.sinks[:len(o.sinks):len(o.sinks)], s)
+	})
+	return func() {
+		_ = updateOptions(func(o *options) {
+			for i, other := range o.sinks {
+				if other == s {
+					o.sinks = append(o.sinks[:i:i], o.sinks[i+1:]...)
+					break
+				}
+			}
+		})
+		s.stop()
+	}, nil
+}
+
+// newSinkState starts the writer goroutine of sink, which runs until stop.
+func newSinkState(sink Sink) (*sinkState, error) {
+	formatter, err := newFormatter(sink.Format, sink.Writer)
+	if err != nil {
+		return nil, err
+	}
+	if sink.QueueSize <= 0 {
+		sink.QueueSize = 1024
+	}
+	s := &sinkState{
+		Sink:      sink,
+		formatter: formatter,
+		queue:     make(chan sinkItem, sink.QueueSize),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// FlushSinks returns once the entries queued for the sinks so far are written,
+// or when ctx is done.
+func FlushSinks(ctx context.Context) error {
+	for _, s := range loadOptions().sinks {
+		written := make(chan struct{})
+		if !s.enqueue(sinkItem{written: written}, ctx.Done()) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue // removed meanwhile, which wrote its queue
+		}
+		select {
+		case <-written:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func route(level Level) *logrus.Logger {
+	o := loadOptions()
+	return routeAt(o, level, o.level)
+}
+
+// routef is route for an entry with the given format, which sampling may leave out.
+func routef(level Level, format string) *logrus.Logger {
+	o := loadOptions()
+	target := routeAt(o, level, o.level)
+	if target != discard && !sample(o, level, format) {
+		return discard
+	}
+	return target
+}
+
+// routeAt returns the logger that should handle an entry of the given level
+// when the main output takes entries up to max: the main logger (or errLogger
+// in split output mode), the tap logger when only sinks want the entry, or discard.
+func routeAt(o *options, level, max Level) *logrus.Logger {
+	if level <= max {
+		if int32(level) <= o.splitThreshold {
+			return errLogger
+		}
+		return logger
+	}
+	if int32(level) <= o.tapLevel {
+		return tap
+	}
+	return discard
+}
+
+// dispatcher is the hook fanning entries out to sinks.
+type dispatcher struct{}
+
+func (dispatcher) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (dispatcher) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[dumpKey]; ok {
+		// recorded already, and counted only now that it is written
+		stats.entries[entry.Level].Add(1)
+		return nil
+	}
+	o := loadOptions()
+	if o.redactor != nil {
+		o.redactor.redact(entry)
+	}
+	if o.time.clock != nil {
+		entry.Time = o.time.clock()
+	}
+	// entries only the recorder wants are not counted
+	emitted := entry.Logger != tap
+
+	rec := o.recorder
+	if rec != nil {
+		rec.dumpBefore(o.dump, entry)
+	}
+	if rec != nil && entry.Level > logrus.Level(rec.level) {
+		rec = nil
+	}
+	if len(o.sinks) == 0 && !o.statsByFile && rec == nil {
+		if emitted {
+			stats.entries[entry.Level].Add(1)
+		}
+		return nil
+	}
+
+	// sinks get the caller resolved and the options of this call in their own data
+	sinkEntry := *entry
+	sinkEntry.Data = make(logrus.Fields, len(entry.Data)+2)
+	for k, v := range entry.Data {
+		sinkEntry.Data[k] = v
+	}
+	if frame, ok := entry.Data[callerKey].(*runtime.Frame); ok {
+		sinkEntry.Caller = frame
+	} else {
+		// Fire runs at the same depth as the caller prettyfier of the main formatter.
+		sinkEntry.Caller = resolveCaller(o.callerSkip)
+		sinkEntry.Data[callerKey] = sinkEntry.Caller
+	}
+	sinkEntry.Data[optionsKey] = o
+
+	// fatal and panic entries are queued to every sink, then waited for together
+	var wait <-chan struct{}
+	var written []chan struct{}
+	if entry.Level <= logrus.FatalLevel {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+		wait = ctx.Done()
+	}
+	var view *Entry
+	if rec != nil {
+		view = newEntry(&sinkEntry)
+		rec.add(view, entry.Logger == tap)
+	}
+	for _, s := range o.sinks {
+		if entry.Level > logrus.Level(s.MinLevel) {
+			continue
+		}
+		if s.Filter != nil {
+			if view == nil {
+				view = newEntry(&sinkEntry)
+			}
+			if !s.Filter(view) {
+				continue
+			}
+		}
+		if done := s.write(&sinkEntry, wait); done != nil {
+			written = append(written, done)
+		}
+		emitted = true
+	}
+	for _, done := range written {
+		select {
+		case <-done:
+		case <-wait:
+		}
+	}
+	if emitted {
+		stats.entries[entry.Level].Add(1)
+		if o.statsByFile {
+			countFile(formatFile(sinkEntry.Caller.File, o.fullpath))
+		}
+	}
+	// errors are reported per sink so that one failing sink never stops the others
+	return nil
+}
+
+// write queues entry for the writer goroutine. With wait, for fatal and
+// panic entries, it waits for room until wait is closed and returns a channel
+// closed once the entry is written.
+func (s *sinkState) write(entry *logrus.Entry, wait <-chan struct{}) chan struct{} {
+	serialized, err := s.formatter.Format(entry)
+	if err != nil {
+		stats.dropped.Add(1)
+		fmt.Fprintf(os.Stderr, "Failed to format entry for sink, %v\n", err)
+		return nil
+	}
+	item := sinkItem{p: serialized}
+	if _, ok := s.Writer.(EntryWriter); ok {
+		item.entry = newEntry(entry)
+	}
+	if wait != nil {
+		item.written = make(chan struct{})
+	}
+	if !s.enqueue(item, wait) {
+		// counted rather than reported, as it happens to many entries at once
+		stats.dropped.Add(1)
+		return nil
+	}
+	return item.written
+}
+
+// enqueue queues item, waiting for room until wait is closed, or not at all
+// when wait is nil, and reports whether it was queued.
+func (s *sinkState) enqueue(item sinkItem, wait <-chan struct{}) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return false
+	}
+	if wait == nil {
+		select {
+		case s.queue <- item:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case s.queue <- item:
+		return true
+	case <-wait:
+		return false
+	}
+}
+
+// stop writes the queued entries and stops the writer goroutine.
+func (s *sinkState) stop() {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *sinkState) run() {
+	defer close(s.done)
+	for item := range s.queue {
+		if item.p != nil {
+			s.writeItem(item)
+		}
+		if item.written != nil {
+			close(item.written)
+		}
+	}
+}
+
+func (s *sinkState) writeItem(item sinkItem) {
+	var err error
+	if w, ok := s.Writer.(EntryWriter); ok {
+		err = w.WriteEntry(item.entry, item.p)
+	} else {
+		_, err = s.Writer.Write(item.p)
+	}
+	if err != nil {
+		stats.dropped.Add(1)
+		fmt.Fprintf(os.Stderr, "Failed to write to sink, %v\n", err)
+	}
+}
+
+type nopFormatter struct{}
+
+func (nopFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
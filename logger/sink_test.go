@@ -0,0 +1,213 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestAddSink(t *testing.T) {
+	textBuf := &bytes.Buffer{}
+	jsonBuf := &bytes.Buffer{}
+	removeText, err := AddSink(Sink{Writer: textBuf, MinLevel: InfoLevel, Format: TextFormat})
+	require.NoError(t, err)
+	removeJSON, err := AddSink(Sink{Writer: jsonBuf, MinLevel: DebugLevel, Format: JSONFormat})
+	require.NoError(t, err)
+
+	output := captureOutput(func() {
+		Debugf("hello=%s", "debug")
+		Infof("hello=%s", "info")
+	})
+	removeText()
+	removeJSON()
+
+	// the main output stays at InfoLevel
+	assert.NotContains(t, output, "hello=debug")
+	assert.Contains(t, output, "hello=info")
+
+	assert.NotContains(t, textBuf.String(), "hello=debug")
+	assert.Regexp(t, `time="[^"]+" level=info msg="hello=info" file="sink_test.go:[0-9]+"`, textBuf.String())
+
+	lines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	require.Len(t, lines, 2)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+	assert.Equal(t, "debug", got["level"])
+	assert.Equal(t, "hello=debug", got["msg"])
+	assert.Regexp(t, `^sink_test.go:[0-9]+$`, got["file"])
+}
+
+func TestAddSink_unknownFormat(t *testing.T) {
+	remove, err := AddSink(Sink{Writer: &bytes.Buffer{}, MinLevel: InfoLevel, Format: "xml"})
+	assert.EqualError(t, err, `unknown format: "xml"`)
+	assert.Nil(t, remove)
+}
+
+func TestAddSink_filter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	remove, err := AddSink(Sink{
+		Writer:   buf,
+		MinLevel: InfoLevel,
+		Filter: func(entry *Entry) bool {
+			return strings.HasPrefix(entry.Message, "keep")
+		},
+	})
+	require.NoError(t, err)
+	_ = captureOutput(func() {
+		Infof("keep me")
+		Infof("drop me")
+	})
+	remove()
+
+	assert.Contains(t, buf.String(), "keep me")
+	assert.NotContains(t, buf.String(), "drop me")
+}
+
+func TestAddSink_failureIsolation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	removeFailing, err := AddSink(Sink{Writer: failingWriter{}, MinLevel: InfoLevel})
+	require.NoError(t, err)
+	removeBuf, err := AddSink(Sink{Writer: buf, MinLevel: InfoLevel})
+	require.NoError(t, err)
+
+	output := captureOutput(func() {
+		Warnf("still delivered")
+	})
+	removeFailing()
+	removeBuf()
+
+	assert.Contains(t, output, "still delivered")
+	assert.Contains(t, buf.String(), "still delivered")
+}
+
+func TestAddSink_remove(t *testing.T) {
+	buf := &bytes.Buffer{}
+	remove, err := AddSink(Sink{Writer: buf, MinLevel: TraceLevel})
+	require.NoError(t, err)
+	assert.Equal(t, int32(TraceLevel), loadOptions().tapLevel)
+	assert.Equal(t, tap, route(DebugLevel))
+
+	remove()
+	assert.Equal(t, int32(-1), loadOptions().tapLevel)
+	assert.Equal(t, discard, route(DebugLevel))
+
+	_ = captureOutput(func() {
+		Infof("after remove")
+	})
+	assert.Empty(t, buf.String())
+}
+
+// blockingWriter blocks every Write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestAddSink_blockingWriter(t *testing.T) {
+	blocked := blockingWriter{release: make(chan struct{})}
+	removeBlocked, err := AddSink(Sink{Writer: blocked, MinLevel: InfoLevel, QueueSize: 2})
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	removeBuf, err := AddSink(Sink{Writer: buf, MinLevel: InfoLevel})
+	require.NoError(t, err)
+	dropped := Stats().Dropped
+
+	done := make(chan string)
+	go func() {
+		done <- captureOutput(func() {
+			for i := 0; i < 10; i++ {
+				Infof("entry %d", i)
+			}
+		})
+	}()
+	var output string
+	select {
+	case output = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging was held up by a blocked sink")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, FlushSinks(ctx), context.DeadlineExceeded)
+	removeBuf()
+
+	assert.Equal(t, 10, strings.Count(output, "entry "))
+	assert.Equal(t, 10, strings.Count(buf.String(), "entry "))
+	// two entries are queued, and one more may be being written
+	assert.Contains(t, []uint64{7, 8}, Stats().Dropped-dropped)
+
+	close(blocked.release)
+	removeBlocked()
+}
+
+// slowWriter takes 300ms to write, counting the writes done.
+type slowWriter struct {
+	writes *atomic.Int32
+}
+
+func (w slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(300 * time.Millisecond)
+	w.writes.Add(1)
+	return len(p), nil
+}
+
+func TestAddSink_slowWriters(t *testing.T) {
+	slow1, slow2 := slowWriter{&atomic.Int32{}}, slowWriter{&atomic.Int32{}}
+	remove1, err := AddSink(Sink{Writer: slow1, MinLevel: ErrorLevel})
+	require.NoError(t, err)
+	remove2, err := AddSink(Sink{Writer: slow2, MinLevel: ErrorLevel})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_ = captureOutput(func() {
+		Errorf("first")
+		Errorf("second")
+	})
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// fatal entries are waited for, in every sink at once
+	require.NoError(t, FlushSinks(context.Background()))
+	start = time.Now()
+	entry := logrus.NewEntry(logger)
+	entry.Level = logrus.FatalLevel
+	entry.Message = "giving up"
+	require.NoError(t, dispatcher{}.Fire(entry))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(3), slow1.writes.Load())
+	assert.Equal(t, int32(3), slow2.writes.Load())
+
+	remove1()
+	remove2()
+}
+
+func TestFlushSinks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	remove, err := AddSink(Sink{Writer: buf, MinLevel: InfoLevel})
+	require.NoError(t, err)
+	defer remove()
+
+	_ = captureOutput(func() {
+		Infof("flushed")
+	})
+	require.NoError(t, FlushSinks(context.Background()))
+	assert.Contains(t, buf.String(), "flushed")
+}
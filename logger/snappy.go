@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"encoding/binary"
+)
+
+// snappyEncode compresses src in the snappy block format, as the Loki push
+// API expects. Matches are looked up greedily in a hash table of 4-byte
+// sequences, within blocks of 64KiB so that offsets fit in two bytes.
+func snappyEncode(src []byte) []byte {
+	dst := make([]byte, 0, binary.MaxVarintLen64+len(src)+len(src)/6)
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	for len(src) > 0 {
+		block := src
+		if len(block) > 1<<16 {
+			block = block[:1<<16]
+		}
+		src = src[len(block):]
+		dst = snappyEncodeBlock(dst, block)
+	}
+	return dst
+}
+
+func snappyEncodeBlock(dst, src []byte) []byte {
+	const tableBits = 14
+	var table [1 << tableBits]int32 // position+1 of the last sequence with each hash
+	literal := 0
+	for i := 0; i+4 <= len(src); {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := (seq * 0x1e35a7bd) >> (32 - tableBits)
+		candidate := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if candidate < 0 || binary.LittleEndian.Uint32(src[candidate:]) != seq {
+			i++
+			continue
+		}
+		n := 4
+		for i+n < len(src) && src[candidate+n] == src[i+n] {
+			n++
+		}
+		dst = appendSnappyLiteral(dst, src[literal:i])
+		dst = appendSnappyCopy(dst, i-candidate, n)
+		i += n
+		literal = i
+	}
+	return appendSnappyLiteral(dst, src[literal:])
+}
+
+func appendSnappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := len(lit) - 1
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	default:
+		// blocks are at most 64KiB
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	}
+	return append(dst, lit...)
+}
+
+// appendSnappyCopy writes copies with a 2-byte offset, of up to 64 bytes each.
+func appendSnappyCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		n := length
+		if n > 64 {
+			n = 64
+		}
+		dst = append(dst, byte(n-1)<<2|2, byte(offset), byte(offset>>8))
+		length -= n
+	}
+	return dst
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snappyDecode decodes the snappy block format.
+func snappyDecode(src []byte) ([]byte, error) {
+	n, read := binary.Uvarint(src)
+	if read <= 0 {
+		return nil, errors.New("bad length")
+	}
+	src = src[read:]
+	dst := make([]byte, 0, n)
+	for len(src) > 0 {
+		tag := src[0]
+		switch tag & 3 {
+		case 0:
+			length := int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				extra := length - 59
+				length = 0
+				for i := 0; i < extra; i++ {
+					length |= int(src[i]) << (8 * i)
+				}
+				src = src[extra:]
+			}
+			length++
+			if length > len(src) {
+				return nil, errors.New("literal too long")
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+		case 2:
+			length := int(tag>>2) + 1
+			offset := int(src[1]) | int(src[2])<<8
+			src = src[3:]
+			if offset == 0 || offset > len(dst) {
+				return nil, errors.New("bad offset")
+			}
+			for i := 0; i < length; i++ {
+				dst = append(dst, dst[len(dst)-offset])
+			}
+		default:
+			return nil, errors.New("unexpected tag")
+		}
+	}
+	if uint64(len(dst)) != n {
+		return nil, errors.New("bad length")
+	}
+	return dst, nil
+}
+
+func TestSnappyEncode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := make([]byte, 100000)
+	r.Read(random)
+	inputs := []string{
+		"",
+		"a",
+		"abcd",
+		strings.Repeat("a", 100000),
+		strings.Repeat(`level=info msg="request served" status=200`+"\n", 3000),
+		string(random),
+	}
+	for _, in := range inputs {
+		encoded := snappyEncode([]byte(in))
+		decoded, err := snappyDecode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, in, string(decoded))
+	}
+
+	logs := []byte(strings.Repeat(`level=info msg="request served" status=200`+"\n", 3000))
+	assert.Less(t, len(snappyEncode(logs)), len(logs)/10)
+}
+
+// TestSnappyEncode_golden pins the encoding of a few inputs, checked against
+// the decoder of github.com/golang/snappy v0.0.4, and the decoding of what
+// its encoder produces for them.
+func TestSnappyEncode_golden(t *testing.T) {
+	testCases := []struct {
+		in        string
+		encoded   string // by snappyEncode
+		reference string // by snappy.Encode
+	}{
+		{"", "00", "00"},
+		{"a", "010061", "010061"},
+		{"abcdabcdabcdabcd", "100c616263642e0400", "103c61626364616263646162636461626364"},
+		{strings.Repeat("a", 100), "640061fe01008a0100", "640061fe01008a0100"},
+		{
+			"0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ!@#$%^&*()",
+			"48f047303132333435363738396162636465666768696a6b6c6d6e6f707172737475767778797a4142434445464748494a4b4c4d4e4f505152535455565758595a21402324255e262a2829",
+			"48f047303132333435363738396162636465666768696a6b6c6d6e6f707172737475767778797a4142434445464748494a4b4c4d4e4f505152535455565758595a21402324255e262a2829",
+		},
+		{
+			strings.Repeat(`level=info msg="request served" status=200`+"\n", 3),
+			"8101a86c6576656c3d696e666f206d73673d22726571756573742073657276656422207374617475733d3230300afe2b00562b00",
+			"8101b06c6576656c3d696e666f206d73673d22726571756573742073657276656422207374617475733d3230300a6c65fe2b004e2b00",
+		},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.encoded, hex.EncodeToString(snappyEncode([]byte(tc.in))))
+
+		reference, err := hex.DecodeString(tc.reference)
+		require.NoError(t, err)
+		decoded, err := snappyDecode(reference)
+		require.NoError(t, err)
+		assert.Equal(t, tc.in, string(decoded))
+	}
+}
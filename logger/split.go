@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"io"
+	"sync"
+)
+
+// SetSplitOutput sends entries at threshold or above to stderr and the rest to stdout,
+// e.g. SetSplitOutput(os.Stdout, os.Stderr, WarnLevel). Calling SetOutput turns it off.
+//
+// Both writers share one lock and are written without buffering, so entries keep
+// their order when stdout and stderr point at the same file.
+func SetSplitOutput(stdout, stderr io.Writer, threshold Level) {
+	_ = updateOptions(func(o *options) {
+		o.setSplitOutput(stdout, stderr, threshold)
+	})
+}
+
+func (o *options) setSplitOutput(stdout, stderr io.Writer, threshold Level) {
+	mu := &sync.Mutex{}
+	o.output = &lockedWriter{mu: mu, w: stdout}
+	o.errOutput = &lockedWriter{mu: mu, w: stderr}
+	o.splitThreshold = int32(threshold)
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+func (lw *lockedWriter) IsTerminal() bool {
+	return isTerminal(lw.w)
+}
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSplitOutput(t *testing.T) {
+	testCases := []struct {
+		threshold  Level
+		wantStdout []string
+		wantStderr []string
+	}{
+		{WarnLevel, []string{"level=info"}, []string{"level=warning", "level=error"}},
+		{ErrorLevel, []string{"level=info", "level=warning"}, []string{"level=error"}},
+		{PanicLevel, []string{"level=info", "level=warning", "level=error"}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.threshold.String(), func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			SetSplitOutput(stdout, stderr, tc.threshold)
+			Debugf("hello")
+			Infof("hello")
+			Warnf("hello")
+			Errorf("hello")
+			SetOutput(os.Stderr)
+
+			assert.Equal(t, tc.wantStdout, levelsOf(stdout.String()))
+			assert.Equal(t, tc.wantStderr, levelsOf(stderr.String()))
+			assert.Regexp(t, `file="split_test.go:[0-9]+"`, stdout.String())
+		})
+	}
+}
+
+func TestSetSplitOutput_sameWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetSplitOutput(buf, buf, WarnLevel)
+	for i := 0; i < 3; i++ {
+		Infof("hello")
+		Errorf("hello")
+	}
+	SetOutput(os.Stderr)
+
+	want := []string{"level=info", "level=error", "level=info", "level=error", "level=info", "level=error"}
+	assert.Equal(t, want, levelsOf(buf.String()))
+}
+
+func TestSetSplitOutput_setOutputTurnsOff(t *testing.T) {
+	SetSplitOutput(&bytes.Buffer{}, &bytes.Buffer{}, WarnLevel)
+	output := captureOutput(func() {
+		Errorf("hello")
+	})
+	require.Equal(t, int32(-1), loadOptions().splitThreshold)
+	assert.Contains(t, output, "level=error")
+}
+
+func levelsOf(output string) []string {
+	var levels []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		for _, field := range strings.Fields(line) {
+			if strings.HasPrefix(field, "level=") {
+				levels = append(levels, field)
+			}
+		}
+	}
+	return levels
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// StatsSnapshot holds the entry counters kept by package logger.
+type StatsSnapshot struct {
+	Entries   map[Level]uint64
+	ByFile    map[string]uint64 // only filled after SetStatsByFile(true)
+	Dropped   uint64            // entries a sink failed to format, queue or write
+	Sampled   uint64            // entries left out by sampling
+	Truncated uint64            // entries shortened to fit an output
+}
+
+var stats struct {
+	entries   [TraceLevel + 1]atomic.Uint64
+	dropped   atomic.Uint64
+	sampled   atomic.Uint64
+	truncated atomic.Uint64
+
+	byFileMu sync.Mutex
+	byFile   map[string]uint64
+}
+
+// SetStatsByFile enables counting entries per caller file as well as per level.
+func SetStatsByFile(enabled bool) {
+	_ = updateOptions(func(o *options) {
+		o.statsByFile = enabled
+	})
+}
+
+func countFile(file string) {
+	stats.byFileMu.Lock()
+	defer stats.byFileMu.Unlock()
+	if stats.byFile == nil {
+		stats.byFile = map[string]uint64{}
+	}
+	stats.byFile[file]++
+}
+
+// Stats returns a snapshot of the current counters.
+func Stats() StatsSnapshot {
+	s := StatsSnapshot{
+		Entries:   make(map[Level]uint64, len(AllLevels)),
+		ByFile:    map[string]uint64{},
+		Dropped:   stats.dropped.Load(),
+		Sampled:   stats.sampled.Load(),
+		Truncated: stats.truncated.Load(),
+	}
+	for _, level := range AllLevels {
+		s.Entries[level] = stats.entries[level].Load()
+	}
+	stats.byFileMu.Lock()
+	for file, count := range stats.byFile {
+		s.ByFile[file] = count
+	}
+	stats.byFileMu.Unlock()
+	return s
+}
+
+// ResetStats sets all counters back to zero.
+func ResetStats() {
+	for i := range stats.entries {
+		stats.entries[i].Store(0)
+	}
+	stats.dropped.Store(0)
+	stats.sampled.Store(0)
+	stats.truncated.Store(0)
+	stats.byFileMu.Lock()
+	stats.byFile = nil
+	stats.byFileMu.Unlock()
+}
+
+// StatsHandler serves the counters in the Prometheus text exposition format.
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(Stats().prometheus()))
+	})
+}
+
+func (s StatsSnapshot) prometheus() string {
+	var sb strings.Builder
+	sb.WriteString("# HELP log_entries_total Number of log entries by level.\n")
+	sb.WriteString("# TYPE log_entries_total counter\n")
+	for _, level := range AllLevels {
+		fmt.Fprintf(&sb, "log_entries_total{level=%q} %d\n", level.String(), s.Entries[level])
+	}
+	if len(s.ByFile) > 0 {
+		files := make([]string, 0, len(s.ByFile))
+		for file := range s.ByFile {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		sb.WriteString("# HELP log_entries_by_file_total Number of log entries by caller file.\n")
+		sb.WriteString("# TYPE log_entries_by_file_total counter\n")
+		for _, file := range files {
+			fmt.Fprintf(&sb, "log_entries_by_file_total{file=\"%s\"} %d\n", escapeLabelValue(file), s.ByFile[file])
+		}
+	}
+	writeCounter(&sb, "log_dropped_entries_total", "Number of log entries a sink failed to format or write.", s.Dropped)
+	writeCounter(&sb, "log_sampled_entries_total", "Number of log entries left out by sampling.", s.Sampled)
+	writeCounter(&sb, "log_truncated_entries_total", "Number of log entries truncated to fit an output.", s.Truncated)
+	return sb.String()
+}
+
+func writeCounter(sb *strings.Builder, name, help string, value uint64) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	ResetStats()
+	_ = captureOutput(func() {
+		Debugf("hello") // dropped by the main level, not counted
+		Infof("hello")
+		Infof("hello")
+		Errorf("hello")
+	})
+
+	got := Stats()
+	assert.Equal(t, uint64(0), got.Entries[DebugLevel])
+	assert.Equal(t, uint64(2), got.Entries[InfoLevel])
+	assert.Equal(t, uint64(1), got.Entries[ErrorLevel])
+	assert.Empty(t, got.ByFile)
+	assert.Equal(t, uint64(0), got.Dropped)
+}
+
+func TestStats_recorder(t *testing.T) {
+	ResetStats()
+	SetRecorder(10, DebugLevel)
+	defer SetRecorder(0, DebugLevel)
+	_ = captureOutput(func() {
+		Debugf("hello") // recorded only, not counted
+		Infof("hello")
+	})
+
+	got := Stats()
+	assert.Equal(t, uint64(0), got.Entries[DebugLevel])
+	assert.Equal(t, uint64(1), got.Entries[InfoLevel])
+	assert.Len(t, Recent(), 2)
+}
+
+func TestStats_byFile(t *testing.T) {
+	ResetStats()
+	SetStatsByFile(true)
+	_ = captureOutput(func() {
+		Infof("hello")
+		Warnf("hello")
+	})
+	SetStatsByFile(false)
+
+	assert.Equal(t, map[string]uint64{"stats_test.go": 2}, Stats().ByFile)
+}
+
+func TestStats_dropped(t *testing.T) {
+	ResetStats()
+	remove, err := AddSink(Sink{Writer: failingWriter{}, MinLevel: DebugLevel})
+	require.NoError(t, err)
+	_ = captureOutput(func() {
+		Debugf("hello")
+		Infof("hello")
+	})
+	remove()
+
+	got := Stats()
+	assert.Equal(t, uint64(1), got.Entries[DebugLevel])
+	assert.Equal(t, uint64(2), got.Dropped)
+}
+
+func TestStatsHandler(t *testing.T) {
+	ResetStats()
+	SetStatsByFile(true)
+	_ = captureOutput(func() {
+		Warnf("hello")
+	})
+	SetStatsByFile(false)
+
+	rec := httptest.NewRecorder()
+	StatsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP log_entries_total Number of log entries by level.
+# TYPE log_entries_total counter
+log_entries_total{level="panic"} 0
+log_entries_total{level="fatal"} 0
+log_entries_total{level="error"} 0
+log_entries_total{level="warning"} 1
+log_entries_total{level="info"} 0
+log_entries_total{level="debug"} 0
+log_entries_total{level="trace"} 0
+# HELP log_entries_by_file_total Number of log entries by caller file.
+# TYPE log_entries_by_file_total counter
+log_entries_by_file_total{file="stats_test.go"} 1
+# HELP log_dropped_entries_total Number of log entries a sink failed to format or write.
+# TYPE log_dropped_entries_total counter
+log_dropped_entries_total 0
+# HELP log_sampled_entries_total Number of log entries left out by sampling.
+# TYPE log_sampled_entries_total counter
+log_sampled_entries_total 0
+# HELP log_truncated_entries_total Number of log entries truncated to fit an output.
+# TYPE log_truncated_entries_total counter
+log_truncated_entries_total 0
+`, string(body))
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+	assert.Equal(t, "plain.go", escapeLabelValue("plain.go"))
+}
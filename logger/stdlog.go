@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"log"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedirectStdLog sends the output of the standard library log package to
+// package logger at the given level, and returns a function undoing it.
+// PanicLevel and FatalLevel are taken as ErrorLevel: log.Fatal and log.Panic
+// stop the program themselves.
+func RedirectStdLog(level Level) (restore func()) {
+	flags, prefix, output := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(newStdLogWriter(level))
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(output)
+	}
+}
+
+// NewStdLog returns a *log.Logger writing to package logger at the given level,
+// for APIs such as http.Server.ErrorLog. Each line becomes one entry.
+// PanicLevel and FatalLevel are taken as ErrorLevel, as for RedirectStdLog.
+func NewStdLog(level Level) *log.Logger {
+	return log.New(newStdLogWriter(level), "", 0)
+}
+
+type stdLogWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func newStdLogWriter(level Level) *stdLogWriter {
+	// a line written at FatalLevel would exit before log.Fatal could
+	if level < ErrorLevel {
+		level = ErrorLevel
+	}
+	return &stdLogWriter{logger: &Logger{}, level: level}
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	o := loadOptions()
+	target := routeAt(o, w.level, w.logger.level(o))
+	if target == discard {
+		return len(p), nil
+	}
+	caller := callerOutside(1, "log")
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line != "" && sample(o, w.level, line) {
+			w.logger.entry(target, caller).Log(logrus.Level(w.level), line)
+		}
+	}
+	return len(p), nil
+}
+
+// callerOutside returns the first frame, starting skip frames above its caller,
+// outside the given standard library packages.
+func callerOutside(skip int, packages ...string) *runtime.Frame {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if frame.File != "" && !inStdPackage(&frame, packages) {
+			return &frame
+		}
+		if !more {
+			return &runtime.Frame{File: "???", Line: 1}
+		}
+	}
+}
+
+// inStdPackage reports whether frame is in one of the given standard library
+// packages, going by both its function and its file, so that packages with
+// the same name elsewhere are not mistaken for them.
+func inStdPackage(frame *runtime.Frame, packages []string) bool {
+	pkg := frame.Function
+	slash := strings.LastIndex(pkg, "/")
+	if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	dir := path.Dir(frame.File)
+	for _, p := range packages {
+		// files are under GOROOT/src, or bare with -trimpath
+		if pkg == p && (dir == p || strings.HasSuffix(dir, "/src/"+p)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectStdLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	defer SetOutput(os.Stderr)
+
+	restore := RedirectStdLog(WarnLevel)
+	log.Printf("hello=%s", "world")
+	log.Println("second")
+	restore()
+	log.Println("restored") // goes to stderr again
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Regexp(t, `^time="[^"]+" level=warning msg="hello=world" file="stdlog_test.go:[0-9]+"$`, string(lines[0]))
+	assert.Regexp(t, `^time="[^"]+" level=warning msg=second file="stdlog_test.go:[0-9]+"$`, string(lines[1]))
+	assert.Equal(t, log.LstdFlags, log.Flags())
+	assert.Equal(t, os.Stderr, log.Writer())
+}
+
+func TestRedirectStdLog_belowLevel(t *testing.T) {
+	output := captureOutput(func() {
+		restore := RedirectStdLog(DebugLevel)
+		log.Printf("hidden")
+		restore()
+	})
+	assert.Empty(t, output)
+}
+
+func TestNewStdLog(t *testing.T) {
+	output := captureOutput(func() {
+		NewStdLog(ErrorLevel).Print("first line\nsecond line\n")
+	})
+	lines := bytes.Split(bytes.TrimSpace([]byte(output)), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Regexp(t, `level=error msg="first line" file="stdlog_test.go:[0-9]+"$`, string(lines[0]))
+	assert.Regexp(t, `level=error msg="second line" file="stdlog_test.go:[0-9]+"$`, string(lines[1]))
+}
+
+func TestNewStdLog_fatalLevel(t *testing.T) {
+	output := captureOutput(func() {
+		NewStdLog(FatalLevel).Print("not exiting")
+	})
+	assert.Regexp(t, `level=error msg="not exiting" file="stdlog_test.go:[0-9]+"\n$`, output)
+}
+
+func TestNewStdLog_sampling(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Sampling = SamplingConfig{Initial: 2, Thereafter: 100, Tick: time.Hour}
+	require.NoError(t, ApplyConfig(cfg))
+	defer resetConfig()
+
+	output := captureOutput(func() {
+		l := NewStdLog(WarnLevel)
+		for i := 0; i < 5; i++ {
+			l.Print("connection reset")
+		}
+	})
+	assert.Equal(t, 2, strings.Count(output, "connection reset"))
+}
+
+func TestInStdPackage(t *testing.T) {
+	testCases := []struct {
+		frame runtime.Frame
+		want  bool
+	}{
+		{runtime.Frame{Function: "log.(*Logger).Output", File: "/usr/local/go/src/log/log.go"}, true},
+		{runtime.Frame{Function: "log.Printf", File: "log/log.go"}, true}, // -trimpath
+		{runtime.Frame{Function: "log.Printf", File: "/src/app/vendor/log/log.go"}, false},
+		{runtime.Frame{Function: "example.com/log.Printf", File: "/usr/local/go/src/log/log.go"}, false},
+		{runtime.Frame{Function: "logutil.Printf", File: "/src/logutil/logutil.go"}, false},
+		{runtime.Frame{Function: "main.main", File: "/src/app/main.go"}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.frame.Function, func(t *testing.T) {
+			assert.Equal(t, tc.want, inStdPackage(&tc.frame, []string{"log"}))
+		})
+	}
+}
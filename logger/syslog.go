@@ -0,0 +1,319 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Facility is a syslog facility.
+type Facility int
+
+const (
+	FacilityUser Facility = iota + 1
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthpriv
+	FacilityFtp
+)
+
+const (
+	FacilityLocal0 Facility = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// syslogSeverities are the syslog severities, by level.
+var syslogSeverities = [...]int{
+	1, // alert
+	2, // crit
+	3, // err
+	4, // warning
+	6, // info
+	7, // debug
+	7, // debug
+}
+
+// SyslogOptions configures a SyslogWriter.
+type SyslogOptions struct {
+	// Network is unix, unixgram, udp or tcp. When empty, the local daemon is
+	// reached through its usual socket and Address is ignored.
+	Network  string
+	Address  string
+	Facility Facility // FacilityUser when 0
+	AppName  string   // the program name when empty
+	Hostname string   // os.Hostname when empty
+	// RFC3164 frames messages in the BSD format rather than in RFC 5424.
+	// Unix sockets always get the format of the local daemon,
+	// <PRI>TIMESTAMP TAG[PID]: MSG.
+	RFC3164 bool
+	// MaxSize is the size of the longest message sent, header included; longer
+	// ones are truncated. 2048 by default, or 1024 with RFC3164.
+	MaxSize int
+	// Fallback receives the entries that cannot be sent; os.Stderr when nil.
+	Fallback io.Writer
+	// ReconnectInterval is the time to wait between connection attempts; 1s when 0.
+	ReconnectInterval time.Duration
+}
+
+// SyslogWriter sends entries to syslog, with their level mapped to the syslog
+// severity. Use it as the Writer of a Sink:
+//
+//	w, err := logger.NewSyslogWriter(logger.SyslogOptions{Facility: logger.FacilityLocal0})
+//	remove, err := logger.AddSink(logger.Sink{Writer: w, MinLevel: logger.InfoLevel})
+//
+// NewSyslogWriter connects, waiting up to 5s, and the writer reconnects in
+// the background after a failure. While syslog cannot be reached, entries go
+// to the fallback writer instead.
+type SyslogWriter struct {
+	opts  SyslogOptions
+	pid   string
+	local bool          // reaching the daemon through a unix socket
+	done  chan struct{} // closed by Close, stopping the connection attempts
+	// mu guards the connection, never held while dialing
+	mu      sync.Mutex
+	conn    net.Conn // nil while disconnected
+	stream  bool
+	dialing bool
+	closed  bool
+}
+
+const syslogDialTimeout = 5 * time.Second
+
+// localSyslogSockets are where the local daemon usually listens.
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+func NewSyslogWriter(opts SyslogOptions) (*SyslogWriter, error) {
+	switch opts.Network {
+	case "", "unix", "unixgram", "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("unknown syslog network: %q", opts.Network)
+	}
+	if opts.Network != "" && opts.Address == "" {
+		return nil, errors.New("missing syslog address")
+	}
+	if opts.Facility < 0 || opts.Facility > FacilityLocal7 {
+		return nil, fmt.Errorf("not a valid syslog facility: %d", opts.Facility)
+	}
+	if opts.Facility == 0 {
+		opts.Facility = FacilityUser
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 2048
+		if opts.RFC3164 {
+			opts.MaxSize = 1024
+		}
+	}
+	if opts.Fallback == nil {
+		opts.Fallback = os.Stderr
+	}
+	if opts.ReconnectInterval <= 0 {
+		opts.ReconnectInterval = time.Second
+	}
+	w := &SyslogWriter{
+		opts:  opts,
+		pid:   strconv.Itoa(os.Getpid()),
+		local: opts.Network == "" || opts.Network == "unix" || opts.Network == "unixgram",
+		done:  make(chan struct{}),
+	}
+	conn, stream, err := w.dial()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		w.reconnect(opts.ReconnectInterval)
+	} else {
+		w.conn, w.stream = conn, stream
+	}
+	return w, nil
+}
+
+// Write sends p with the info severity.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	if err := w.WriteEntry(&Entry{Time: time.Now(), Level: InfoLevel}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements EntryWriter, sending the rendering p of entry.
+func (w *SyslogWriter) WriteEntry(entry *Entry, p []byte) error {
+	msg := w.frame(entry, p)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if err := w.send(msg); err == nil {
+			return nil
+		}
+		w.close()
+		// the connection may have gone stale: try a new one right away
+		w.reconnect(0)
+	}
+	_, err := w.opts.Fallback.Write(p)
+	return err
+}
+
+// Close closes the connection to syslog.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.done)
+	}
+	return w.close()
+}
+
+func (w *SyslogWriter) close() error {
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// reconnect starts connecting in the background after delay, unless it
+// already is. w.mu must be held.
+func (w *SyslogWriter) reconnect(delay time.Duration) {
+	if w.dialing || w.closed {
+		return
+	}
+	w.dialing = true
+	go w.connect(delay)
+}
+
+// connect dials after delay until it connects, every ReconnectInterval, or
+// until Close.
+func (w *SyslogWriter) connect(delay time.Duration) {
+	for {
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-w.done:
+				return
+			}
+		}
+		delay = w.opts.ReconnectInterval
+		conn, stream, err := w.dial()
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		if err == nil {
+			w.conn, w.stream, w.dialing = conn, stream, false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *SyslogWriter) send(msg []byte) error {
+	if w.stream {
+		if w.local || w.opts.RFC3164 {
+			// newline delimited, as daemons expect of the local and BSD formats
+			msg = append(msg, '\n')
+		} else {
+			// octet counting, RFC 6587
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+	}
+	_, err := w.conn.Write(msg)
+	return err
+}
+
+// dial connects to syslog, reporting whether the connection is a stream.
+func (w *SyslogWriter) dial() (net.Conn, bool, error) {
+	if w.opts.Network != "" {
+		conn, err := net.DialTimeout(w.opts.Network, w.opts.Address, syslogDialTimeout)
+		if err != nil {
+			return nil, false, err
+		}
+		return conn, w.opts.Network == "unix" || w.opts.Network[:3] == "tcp", nil
+	}
+	var err error
+	for _, path := range localSyslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			if conn, err = net.DialTimeout(network, path, syslogDialTimeout); err == nil {
+				return conn, network == "unix", nil
+			}
+		}
+	}
+	return nil, false, fmt.Errorf("no local syslog daemon: %w", err)
+}
+
+// frame renders the syslog message for entry, without the transport framing.
+func (w *SyslogWriter) frame(entry *Entry, p []byte) []byte {
+	severity := 7
+	if entry.Level <= TraceLevel {
+		severity = syslogSeverities[entry.Level]
+	}
+	pri := int(w.opts.Facility)*8 + severity
+
+	var header string
+	if w.local {
+		// <PRI>Mmm dd hh:mm:ss TAG[PID]: MSG
+		header = fmt.Sprintf("<%d>%s %s[%s]: ", pri, entry.Time.Format(time.Stamp), w.opts.AppName, w.pid)
+	} else if w.opts.RFC3164 {
+		// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+		header = fmt.Sprintf("<%d>%s %s %s[%s]: ", pri, entry.Time.Format(time.Stamp), w.opts.Hostname, w.opts.AppName, w.pid)
+	} else {
+		// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+		header = fmt.Sprintf("<%d>1 %s %s %s %s - - ", pri, entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"), nilValue(w.opts.Hostname), nilValue(w.opts.AppName), w.pid)
+	}
+	body := p
+	for len(body) > 0 && body[len(body)-1] == '\n' {
+		body = body[:len(body)-1]
+	}
+	if len(header)+len(body) > w.opts.MaxSize {
+		stats.truncated.Add(1)
+		n := w.opts.MaxSize - len(header)
+		if n < 0 {
+			n = 0
+		}
+		// cut at a rune boundary
+		for n > 0 && !utf8.RuneStart(body[n]) {
+			n--
+		}
+		body = body[:n]
+	}
+	msg := make([]byte, 0, len(header)+len(body)+1)
+	msg = append(msg, header...)
+	return append(msg, body...)
+}
+
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,250 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitConnected waits for w to reconnect in the background.
+func waitConnected(t *testing.T, w *SyslogWriter) {
+	assert.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.conn != nil
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestSyslogWriter_frame(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	entry := &Entry{Time: time.Date(2023, 10, 18, 12, 0, 0, 123456789, time.UTC), Level: WarnLevel}
+
+	w, err := NewSyslogWriter(SyslogOptions{Network: "udp", Address: "localhost:514", Hostname: "host", AppName: "app"})
+	require.NoError(t, err)
+	assert.Equal(t, "<12>1 2023-10-18T12:00:00.123456Z host app "+pid+" - - hello world", string(w.frame(entry, []byte("hello world\n"))))
+	w.Close()
+
+	w, err = NewSyslogWriter(SyslogOptions{Network: "unixgram", Address: "/dev/log", Hostname: "host", AppName: "app"})
+	require.NoError(t, err)
+	assert.Equal(t, "<12>Oct 18 12:00:00 app["+pid+"]: hello world", string(w.frame(entry, []byte("hello world\n"))))
+	w.Close()
+
+	w, err = NewSyslogWriter(SyslogOptions{Network: "udp", Address: "localhost:514", Hostname: "host", AppName: "app", Facility: FacilityLocal0, RFC3164: true})
+	require.NoError(t, err)
+	defer w.Close()
+	assert.Equal(t, "<132>Oct 18 12:00:00 host app["+pid+"]: hello world", string(w.frame(entry, []byte("hello world\n"))))
+
+	testCases := []struct {
+		level Level
+		pri   string
+	}{
+		{PanicLevel, "<129>"},
+		{FatalLevel, "<130>"},
+		{ErrorLevel, "<131>"},
+		{WarnLevel, "<132>"},
+		{InfoLevel, "<134>"},
+		{DebugLevel, "<135>"},
+		{TraceLevel, "<135>"},
+	}
+	for _, tc := range testCases {
+		entry.Level = tc.level
+		assert.True(t, strings.HasPrefix(string(w.frame(entry, nil)), tc.pri), tc.level.String())
+	}
+}
+
+func TestSyslogWriter_truncate(t *testing.T) {
+	ResetStats()
+	w, err := NewSyslogWriter(SyslogOptions{Network: "udp", Address: "localhost:514", Hostname: "host", AppName: "app", MaxSize: 64})
+	require.NoError(t, err)
+	defer w.Close()
+	msg := w.frame(&Entry{Time: time.Now(), Level: InfoLevel}, []byte(strings.Repeat("é", 100)))
+	assert.LessOrEqual(t, len(msg), 64)
+	assert.True(t, strings.HasSuffix(string(msg), "é"))
+	assert.Equal(t, uint64(1), Stats().Truncated)
+}
+
+func TestNewSyslogWriter_invalid(t *testing.T) {
+	_, err := NewSyslogWriter(SyslogOptions{Network: "http", Address: "localhost:514"})
+	assert.EqualError(t, err, `unknown syslog network: "http"`)
+	_, err = NewSyslogWriter(SyslogOptions{Network: "udp"})
+	assert.EqualError(t, err, "missing syslog address")
+	_, err = NewSyslogWriter(SyslogOptions{Network: "udp", Address: "localhost:514", Facility: 24})
+	assert.EqualError(t, err, "not a valid syslog facility: 24")
+}
+
+func TestSyslogWriter_udp(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fallback := &bytes.Buffer{}
+	w, err := NewSyslogWriter(SyslogOptions{Network: "udp", Address: conn.LocalAddr().String(), AppName: "app", Fallback: fallback})
+	require.NoError(t, err)
+	defer w.Close()
+	remove, err := AddSink(Sink{Writer: w, MinLevel: WarnLevel, Format: JSONFormat})
+	require.NoError(t, err)
+	defer remove()
+
+	Warnf("disk %d%% full", 90)
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Regexp(t, `^<12>1 \S+ \S+ app [0-9]+ - - \{.*"msg":"disk 90% full".*\}$`, string(buf[:n]))
+	// the first entry is sent, connected by NewSyslogWriter
+	assert.Empty(t, fallback.String())
+}
+
+func TestSyslogWriter_tcp(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			// octet counting: "LEN SP MSG"
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	w, err := NewSyslogWriter(SyslogOptions{Network: "tcp", Address: ln.Addr().String(), AppName: "app"})
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, w.WriteEntry(&Entry{Time: time.Now(), Level: ErrorLevel}, []byte("first\n")))
+	require.NoError(t, w.WriteEntry(&Entry{Time: time.Now(), Level: InfoLevel}, []byte("second\n")))
+
+	for _, want := range []string{`^<11>1 .* first$`, `^<14>1 .* second$`} {
+		select {
+		case msg := <-received:
+			assert.Regexp(t, want, msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestSyslogWriter_reconnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	listen := func() net.PacketConn {
+		conn, err := net.ListenPacket("unixgram", path)
+		require.NoError(t, err)
+		return conn
+	}
+	read := func(conn net.PacketConn) string {
+		buf := make([]byte, 4096)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+
+	conn := listen()
+	fallback := &bytes.Buffer{}
+	w, err := NewSyslogWriter(SyslogOptions{Network: "unixgram", Address: path, Fallback: fallback, ReconnectInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("before\n"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(read(conn), " before"))
+
+	// the daemon goes away: entries go to the fallback
+	conn.Close()
+	os.Remove(path)
+	_, err = w.Write([]byte("while down\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "while down\n", fallback.String())
+
+	// and comes back
+	conn = listen()
+	defer conn.Close()
+	waitConnected(t, w)
+	_, err = w.Write([]byte("after\n"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(read(conn), " after"))
+	assert.Equal(t, "while down\n", fallback.String())
+}
+
+func TestSyslogWriter_unreachable(t *testing.T) {
+	// TEST-NET-1 is not routed: dialing fails or hangs until syslogDialTimeout
+	fallback := &bytes.Buffer{}
+	w, err := NewSyslogWriter(SyslogOptions{Network: "tcp", Address: "192.0.2.1:514", Fallback: fallback, ReconnectInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer w.Close()
+
+	// entries are not held up by the attempts to reconnect
+	time.Sleep(5 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err = w.Write([]byte("hello\n"))
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, "hello\nhello\nhello\n", fallback.String())
+}
+
+func TestSyslogWriter_unixStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer ln.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- line
+		}
+	}()
+
+	w, err := NewSyslogWriter(SyslogOptions{Network: "unix", Address: path, AppName: "app"})
+	require.NoError(t, err)
+	defer w.Close()
+	t0 := time.Date(2023, 10, 18, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, w.WriteEntry(&Entry{Time: t0, Level: ErrorLevel}, []byte("first\n")))
+	require.NoError(t, w.WriteEntry(&Entry{Time: t0, Level: InfoLevel}, []byte("second\n")))
+
+	pid := strconv.Itoa(os.Getpid())
+	for _, want := range []string{"<11>Oct 18 12:00:00 app[" + pid + "]: first\n", "<14>Oct 18 12:00:00 app[" + pid + "]: second\n"} {
+		select {
+		case msg := <-received:
+			assert.Equal(t, want, msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	}
+}
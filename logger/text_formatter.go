@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// textFormatter renders entries the way logrus.TextFormatter does: as logfmt,
+// or with coloured levels and keys when the output is a terminal.
+type textFormatter struct {
+	callerPrettyfier func(*runtime.Frame) (string, string)
+	color            bool
+}
+
+func (f *textFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	o := optionsOf(entry)
+	data := entryData(entry.Data)
+	static := o.defaultsFor(data)
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var funcVal, fileVal string
+	if entry.HasCaller() {
+		// called from here, at the depth the caller prettyfier expects
+		if frame, ok := entry.Data[callerKey].(*runtime.Frame); ok {
+			funcVal, fileVal = o.frameCaller(frame)
+		} else {
+			funcVal, fileVal = f.callerPrettyfier(entry.Caller)
+		}
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+	if f.color {
+		if static != nil {
+			for k, v := range static.fields {
+				data[k] = v
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+		}
+		appendColored(b, entry, o, funcVal, fileVal, keys, data)
+		b.WriteByte('\n')
+		return b.Bytes(), nil
+	}
+	if ts, ok := o.timestamp(entry.Time); ok {
+		appendKeyValue(b, logrus.FieldKeyTime, ts)
+	}
+	appendKeyValue(b, logrus.FieldKeyLevel, entry.Level.String())
+	if entry.Message != "" {
+		appendKeyValue(b, logrus.FieldKeyMsg, entry.Message)
+	}
+	if funcVal != "" {
+		appendKeyValue(b, logrus.FieldKeyFunc, funcVal)
+	}
+	if fileVal != "" {
+		appendKeyValue(b, logrus.FieldKeyFile, fileVal)
+	}
+	for _, key := range keys {
+		appendKeyValue(b, key, data[key])
+	}
+	if static != nil {
+		b.Write(static.text)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// appendColored follows the printColored method of logrus.TextFormatter.
+func appendColored(b *bytes.Buffer, entry *logrus.Entry, o *options, funcVal, fileVal string, keys []string, data logrus.Fields) {
+	color := levelColor(entry.Level)
+	levelText := strings.ToUpper(entry.Level.String())[:4]
+	message := strings.TrimSuffix(entry.Message, "\n")
+	caller := fileVal
+	switch {
+	case fileVal == "":
+		caller = funcVal
+	case funcVal != "":
+		caller = fileVal + " " + funcVal
+	}
+	if ts, ok := o.timestamp(entry.Time); ok {
+		fmt.Fprintf(b, "%s%s%s[%v]%s %-44s ", color, levelText, colorReset, ts, caller, message)
+	} else {
+		fmt.Fprintf(b, "%s%s%s%s %-44s ", color, levelText, colorReset, caller, message)
+	}
+	for _, key := range keys {
+		fmt.Fprintf(b, " %s%s%s=", color, key, colorReset)
+		appendValue(b, data[key])
+	}
+}
+
+func appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	appendValue(b, value)
+}
+
+func appendValue(b *bytes.Buffer, value interface{}) {
+	stringVal, ok := value.(string)
+	if !ok {
+		stringVal = fmt.Sprint(value)
+	}
+	if needsQuoting(stringVal) {
+		fmt.Fprintf(b, "%q", stringVal)
+	} else {
+		b.WriteString(stringVal)
+	}
+}
+
+func needsQuoting(text string) bool {
+	for _, ch := range text {
+		if !((ch >= 'a' && ch <= 'z') ||
+			(ch >= 'A' && ch <= 'Z') ||
+			(ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '.' || ch == '_' || ch == '/' || ch == '@' || ch == '^' || ch == '+') {
+			return true
+		}
+	}
+	return false
+}
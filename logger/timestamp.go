@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"time"
+)
+
+// EpochMillis is a SetTimeLayout value rendering timestamps as milliseconds since the Unix epoch.
+const EpochMillis = "epochmillis"
+
+type timeOptions struct {
+	layout   string
+	location *time.Location
+	clock    func() time.Time
+}
+
+// SetTimeLayout sets the layout of timestamps: a time.Format layout such as time.RFC3339Nano,
+// or EpochMillis. An empty layout omits the timestamp, e.g. when running under journald.
+// GCPFormat, ECSFormat and OTelFormat keep the timestamps their schema requires.
+func SetTimeLayout(layout string) {
+	_ = updateOptions(func(o *options) {
+		o.time.layout = layout
+	})
+}
+
+// SetTimeLocation sets the time zone of timestamps, e.g. time.UTC. nil means local time.
+func SetTimeLocation(location *time.Location) {
+	_ = updateOptions(func(o *options) {
+		o.time.location = location
+	})
+}
+
+// SetClock replaces time.Now as the source of entry timestamps. nil restores time.Now.
+func SetClock(clock func() time.Time) {
+	_ = updateOptions(func(o *options) {
+		o.time.clock = clock
+	})
+}
+
+// timestamp renders t according to the time options, or returns false when
+// timestamps are omitted.
+func (o *options) timestamp(t time.Time) (interface{}, bool) {
+	switch o.time.layout {
+	case "":
+		return nil, false
+	case EpochMillis:
+		return t.UnixMilli(), true
+	}
+	if o.time.location != nil {
+		t = t.In(o.time.location)
+	}
+	return t.Format(o.time.layout), true
+}
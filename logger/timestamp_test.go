@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var frozen = time.Date(2023, 10, 18, 12, 0, 0, 123456789, time.FixedZone("KST", 9*60*60))
+
+func TestSetClock(t *testing.T) {
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+
+	output := captureOutput(func() {
+		Infof("hello")
+	})
+	assert.Regexp(t, `^time="2023-10-18T12:00:00\+09:00" level=info msg=hello file="timestamp_test.go:[0-9]+"\n$`, output)
+}
+
+func TestSetTimeLayout(t *testing.T) {
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+	defer SetTimeLayout(time.RFC3339)
+
+	testCases := []struct {
+		layout string
+		want   string
+	}{
+		{time.RFC3339, `time="2023-10-18T12:00:00+09:00" level=info`},
+		{time.RFC3339Nano, `time="2023-10-18T12:00:00.123456789+09:00" level=info`},
+		{"2006-01-02 15:04:05.000", `time="2023-10-18 12:00:00.123" level=info`},
+		{EpochMillis, `time=1697598000123 level=info`},
+		{"", `level=info`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.layout, func(t *testing.T) {
+			SetTimeLayout(tc.layout)
+			output := captureOutput(func() {
+				Infof("hello")
+			})
+			assert.Contains(t, output, tc.want)
+			if tc.layout == "" {
+				assert.NotContains(t, output, "time=")
+			}
+		})
+	}
+}
+
+func TestSetTimeLocation(t *testing.T) {
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+	SetTimeLocation(time.UTC)
+	defer SetTimeLocation(nil)
+
+	output := captureOutput(func() {
+		Infof("hello")
+	})
+	assert.Contains(t, output, `time="2023-10-18T03:00:00Z"`)
+}
+
+func TestSetClock_sink(t *testing.T) {
+	SetClock(func() time.Time { return frozen })
+	defer SetClock(nil)
+	SetTimeLayout(EpochMillis)
+	defer SetTimeLayout(time.RFC3339)
+
+	buf := &bytes.Buffer{}
+	remove, err := AddSink(Sink{Writer: buf, MinLevel: InfoLevel, Format: JSONFormat})
+	require.NoError(t, err)
+	_ = captureOutput(func() {
+		Infof("hello")
+	})
+	remove()
+
+	assert.Regexp(t, `^\{"file":"timestamp_test.go:[0-9]+","level":"info","msg":"hello","time":1697598000123\}\n$`, buf.String())
+}
@@ -1,6 +1,11 @@
 package logger
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,11 +21,79 @@ const (
 	TraceLevel Level = Level(logrus.TraceLevel)
 )
 
+var levelAliases = map[string]Level{
+	"panic":    PanicLevel,
+	"fatal":    FatalLevel,
+	"crit":     FatalLevel,
+	"critical": FatalLevel,
+	"error":    ErrorLevel,
+	"err":      ErrorLevel,
+	"warn":     WarnLevel,
+	"warning":  WarnLevel,
+	"info":     InfoLevel,
+	"debug":    DebugLevel,
+	"trace":    TraceLevel,
+}
+
 func (level Level) String() string {
 	return logrus.Level(level).String()
 }
 
+// ParseLevel parses a level name, case-insensitively, one of its aliases
+// (err, crit, critical) or its number.
 func ParseLevel(lvl string) (Level, error) {
-	level, err := logrus.ParseLevel(lvl)
-	return Level(level), err
+	s := strings.ToLower(strings.TrimSpace(lvl))
+	if level, ok := levelAliases[s]; ok {
+		return level, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= int(PanicLevel) && n <= int(TraceLevel) {
+		return Level(n), nil
+	}
+	names := make([]string, len(AllLevels))
+	for i, level := range AllLevels {
+		names[i] = level.String()
+	}
+	return PanicLevel, fmt.Errorf("not a valid Level: %q (want one of %s)", lvl, strings.Join(names, ", "))
+}
+
+// Set implements flag.Value.
+func (level *Level) Set(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*level = l
+	return nil
+}
+
+func (level Level) MarshalText() ([]byte, error) {
+	if level > TraceLevel {
+		return nil, fmt.Errorf("not a valid Level: %d", level)
+	}
+	return []byte(level.String()), nil
+}
+
+func (level *Level) UnmarshalText(text []byte) error {
+	return level.Set(string(text))
+}
+
+func (level Level) MarshalJSON() ([]byte, error) {
+	text, err := level.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON accepts a level as a string or a number.
+func (level *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if json.Unmarshal(data, &n) != nil {
+			return fmt.Errorf("not a valid Level: %s", data)
+		}
+		s = n.String()
+	}
+	return level.Set(s)
 }
@@ -1,8 +1,13 @@
 package logger
 
 import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"io"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,8 +54,18 @@ func TestParseLevel(t *testing.T) {
 		{"DEBUG", DebugLevel, ``},
 		{"trace", TraceLevel, ``},
 		{"TRACE", TraceLevel, ``},
-		{"invalid", PanicLevel, `not a valid logrus Level: "invalid"`},
-		{"foo", PanicLevel, `not a valid logrus Level: "foo"`},
+		{"Warning", WarnLevel, ``},
+		{"err", ErrorLevel, ``},
+		{"crit", FatalLevel, ``},
+		{"CRITICAL", FatalLevel, ``},
+		{" info ", InfoLevel, ``},
+		{"0", PanicLevel, ``},
+		{"4", InfoLevel, ``},
+		{"6", TraceLevel, ``},
+		{"7", PanicLevel, `not a valid Level: "7" (want one of panic, fatal, error, warning, info, debug, trace)`},
+		{"-1", PanicLevel, `not a valid Level: "-1" (want one of panic, fatal, error, warning, info, debug, trace)`},
+		{"invalid", PanicLevel, `not a valid Level: "invalid" (want one of panic, fatal, error, warning, info, debug, trace)`},
+		{"foo", PanicLevel, `not a valid Level: "foo" (want one of panic, fatal, error, warning, info, debug, trace)`},
 	}
 	for _, tc := range testCases {
 		t.Run("", func(t *testing.T) {
@@ -64,3 +79,71 @@ func TestParseLevel(t *testing.T) {
 		})
 	}
 }
+
+var (
+	_ flag.Value               = new(Level)
+	_ encoding.TextMarshaler   = InfoLevel
+	_ encoding.TextUnmarshaler = new(Level)
+	_ json.Marshaler           = InfoLevel
+	_ json.Unmarshaler         = new(Level)
+)
+
+func TestLevel_flag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	level := InfoLevel
+	fs.Var(&level, "level", "log level")
+	fs.SetOutput(io.Discard)
+
+	require.NoError(t, fs.Parse([]string{"-level", "DEBUG"}))
+	assert.Equal(t, DebugLevel, level)
+	assert.EqualError(t, fs.Parse([]string{"-level", "loud"}), `invalid value "loud" for flag -level: not a valid Level: "loud" (want one of panic, fatal, error, warning, info, debug, trace)`)
+	assert.Equal(t, DebugLevel, level)
+}
+
+func TestLevel_text(t *testing.T) {
+	text, err := WarnLevel.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "warning", string(text))
+
+	_, err = Level(9).MarshalText()
+	assert.EqualError(t, err, "not a valid Level: 9")
+
+	var level Level
+	require.NoError(t, level.UnmarshalText([]byte("Trace")))
+	assert.Equal(t, TraceLevel, level)
+	assert.Error(t, level.UnmarshalText([]byte("loud")))
+}
+
+func TestLevel_json(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+	data, err := json.Marshal(config{Level: ErrorLevel})
+	require.NoError(t, err)
+	assert.Equal(t, `{"level":"error"}`, string(data))
+
+	testCases := []struct {
+		data      string
+		want      Level
+		wantError string
+	}{
+		{`{"level":"debug"}`, DebugLevel, ``},
+		{`{"level":"WARN"}`, WarnLevel, ``},
+		{`{"level":5}`, DebugLevel, ``},
+		{`{"level":"5"}`, DebugLevel, ``},
+		{`{"level":true}`, InfoLevel, `not a valid Level: true`},
+		{`{"level":"loud"}`, InfoLevel, `not a valid Level: "loud" (want one of panic, fatal, error, warning, info, debug, trace)`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			got := config{Level: InfoLevel}
+			err := json.Unmarshal([]byte(tc.data), &got)
+			if tc.wantError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.wantError)
+			}
+			assert.Equal(t, tc.want, got.Level)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultWebhookTemplate renders the payload of Slack and Microsoft Teams
+// incoming webhooks.
+const DefaultWebhookTemplate = `{"text": {{json .Text}}}`
+
+// WebhookOptions configures a WebhookExporter.
+type WebhookOptions struct {
+	URLs []string // each destination receives every payload
+	// Template is a text/template rendering the request body from a
+	// WebhookPayload, DefaultWebhookTemplate when empty. Its json function
+	// renders a value as JSON, such as a string with quotes and escapes.
+	Template    string
+	ContentType string            // application/json when empty
+	Headers     map[string]string // added to every request
+	// MinInterval is the shortest time between two requests to a
+	// destination, 1s when 0. Entries arriving meanwhile wait for the next
+	// request, except for flushes, such as those of Close and fatal entries,
+	// which are posted right away.
+	MinInterval time.Duration
+	Client      *http.Client // with a 10s timeout when nil
+	// MaxAlerts and MaxTextSize bound the alerts of a payload and the bytes
+	// of its Text, 50 and 40000 when 0, the alerts left out being counted in
+	// a last line of Text. Slack takes up to 40000 characters, Teams about
+	// 28KB in all.
+	MaxAlerts   int
+	MaxTextSize int
+	// Batch.FlushInterval is also the window identical entries are grouped
+	// in, 10s when 0 rather than 1s.
+	Batch BatchOptions
+}
+
+// WebhookPayload is what the template of a WebhookExporter renders.
+type WebhookPayload struct {
+	Alerts []WebhookAlert
+	// Text is the Line of each alert, followed by "(repeated n times)" when
+	// it groups several entries, one per line, and "... and n more" when
+	// alerts were left out.
+	Text    string
+	Omitted int // alerts left out for MaxAlerts or MaxTextSize
+}
+
+// WebhookAlert is a group of entries with the same level, logger and message.
+type WebhookAlert struct {
+	Level   Level
+	Logger  string
+	Message string
+	Fields  Fields // of the first entry
+	Line    string // the first entry rendered in the Format of the sink
+	Count   int
+	First   time.Time
+	Last    time.Time
+}
+
+// WebhookExporter posts entries to webhooks, such as Slack or Teams incoming
+// webhooks, grouping identical entries sent within a window into one alert.
+// Use it as the Writer of a Sink with MinLevel ErrorLevel. Fatal entries are
+// posted before the program exits.
+type WebhookExporter struct {
+	opts         WebhookOptions
+	template     *template.Template
+	destinations []*webhookDestination
+}
+
+// webhookDestination has its own batcher, so that a slow or failing webhook
+// neither delays nor duplicates the payloads of the others.
+type webhookDestination struct {
+	url     string
+	name    string // for messages, as webhook URLs hold their token
+	batcher *batcher
+}
+
+func NewWebhookExporter(opts WebhookOptions) (*WebhookExporter, error) {
+	if len(opts.URLs) == 0 {
+		return nil, errors.New("missing webhook URL")
+	}
+	if opts.Template == "" {
+		opts.Template = DefaultWebhookTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{"json": webhookJSON}).Parse(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("parsing webhook template: %w", err)
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = "application/json"
+	}
+	if opts.MinInterval <= 0 {
+		opts.MinInterval = time.Second
+	}
+	if opts.MaxAlerts <= 0 {
+		opts.MaxAlerts = 50
+	}
+	if opts.MaxTextSize <= 0 {
+		opts.MaxTextSize = 40000
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Batch.FlushInterval <= 0 {
+		opts.Batch.FlushInterval = 10 * time.Second
+	}
+	// the batcher delays requests rather than send, which would hold up flushes
+	opts.Batch.minInterval = opts.MinInterval
+	x := &WebhookExporter{opts: opts, template: tmpl}
+	for i, rawURL := range opts.URLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			// the parse error would show the URL
+			return nil, fmt.Errorf("invalid webhook URL %d", i+1)
+		}
+		d := &webhookDestination{url: rawURL, name: fmt.Sprintf("webhook %d (%s)", i+1, u.Host)}
+		d.batcher = newBatcher(d.name, opts.Batch, func(ctx context.Context, batch []batchItem) error {
+			return x.send(ctx, d, batch)
+		})
+		x.destinations = append(x.destinations, d)
+	}
+	return x, nil
+}
+
+// Write posts p as the line of an error entry.
+func (x *WebhookExporter) Write(p []byte) (int, error) {
+	if err := x.WriteEntry(&Entry{Time: time.Now(), Level: ErrorLevel, Message: strings.TrimSuffix(string(p), "\n")}, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteEntry implements EntryWriter, queueing entry for the next payload of
+// every destination.
+func (x *WebhookExporter) WriteEntry(entry *Entry, p []byte) error {
+	errs := make([]error, len(x.destinations))
+	if entry.Level > FatalLevel {
+		for i, d := range x.destinations {
+			errs[i] = d.batcher.add(entry, p)
+		}
+		return errors.Join(errs...)
+	}
+	// fatal entries flush the destinations, all within the same timeout
+	var wg sync.WaitGroup
+	for i, d := range x.destinations {
+		wg.Add(1)
+		go func(i int, d *webhookDestination) {
+			defer wg.Done()
+			errs[i] = d.batcher.add(entry, p)
+		}(i, d)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Flush posts the entries queued so far.
+func (x *WebhookExporter) Flush(ctx context.Context) error {
+	errs := make([]error, len(x.destinations))
+	for i, d := range x.destinations {
+		errs[i] = d.batcher.flush(ctx)
+	}
+	return errors.Join(errs...)
+}
+
+// Close posts the queued entries, waiting for up to 5s, and stops the exporter.
+func (x *WebhookExporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	errs := make([]error, len(x.destinations))
+	for i, d := range x.destinations {
+		errs[i] = d.batcher.close(ctx)
+	}
+	return errors.Join(errs...)
+}
+
+func (x *WebhookExporter) send(ctx context.Context, d *webhookDestination, batch []batchItem) error {
+	var body bytes.Buffer
+	if err := x.template.Execute(&body, webhookPayload(batch, x.opts.MaxAlerts, x.opts.MaxTextSize)); err != nil {
+		return &permanentError{err: err}
+	}
+	_, err := postBatch(ctx, x.opts.Client, d.url, x.opts.ContentType, x.opts.Headers, body.Bytes())
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = d.name
+	}
+	return err
+}
+
+// webhookPayload groups the entries of batch into alerts, in the order of
+// their first entry, keeping those fitting in maxAlerts and maxText.
+func webhookPayload(batch []batchItem, maxAlerts, maxText int) WebhookPayload {
+	type key struct {
+		level   Level
+		logger  string
+		message string
+	}
+	index := map[key]int{}
+	var payload WebhookPayload
+	for _, item := range batch {
+		e := item.entry
+		logger, _ := e.Fields["logger"].(string)
+		k := key{e.Level, logger, e.Message}
+		if i, ok := index[k]; ok {
+			a := &payload.Alerts[i]
+			a.Count++
+			if e.Time.Before(a.First) {
+				a.First = e.Time
+			}
+			if e.Time.After(a.Last) {
+				a.Last = e.Time
+			}
+			continue
+		}
+		index[k] = len(payload.Alerts)
+		payload.Alerts = append(payload.Alerts, WebhookAlert{
+			Level:   e.Level,
+			Logger:  logger,
+			Message: e.Message,
+			Fields:  e.Fields,
+			Line:    strings.TrimSuffix(string(item.p), "\n"),
+			Count:   1,
+			First:   e.Time,
+			Last:    e.Time,
+		})
+	}
+	lines := make([]string, len(payload.Alerts))
+	size := 0
+	for i, a := range payload.Alerts {
+		lines[i] = a.Line
+		if a.Count > 1 {
+			lines[i] += fmt.Sprintf(" (repeated %d times)", a.Count)
+		}
+		if i > 0 {
+			lines[i] = "\n" + lines[i]
+		}
+		size += len(lines[i])
+	}
+	var text strings.Builder
+	shown := len(lines)
+	if len(lines) > maxAlerts || size > maxText {
+		shown = 0
+		for i, line := range lines {
+			// room is left for the line counting the alerts after this one
+			room := maxText - text.Len()
+			if rest := len(lines) - i - 1; rest > 0 {
+				room -= len("\n" + omittedLine(rest))
+			}
+			if i == maxAlerts || len(line) > room {
+				if i == 0 && room > 0 {
+					// an alert too long on its own is cut rather than left out
+					text.WriteString(truncateUTF8(line, room))
+					shown = 1
+				}
+				break
+			}
+			text.WriteString(line)
+			shown++
+		}
+	} else {
+		for _, line := range lines {
+			text.WriteString(line)
+		}
+	}
+	if payload.Omitted = len(payload.Alerts) - shown; payload.Omitted > 0 {
+		if shown > 0 {
+			text.WriteByte('\n')
+		}
+		text.WriteString(omittedLine(payload.Omitted))
+		payload.Alerts = payload.Alerts[:shown]
+	}
+	payload.Text = text.String()
+	return payload
+}
+
+func omittedLine(n int) string {
+	return fmt.Sprintf("... and %d more", n)
+}
+
+// truncateUTF8 cuts s to at most n bytes, at a rune boundary.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func webhookJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
@@ -0,0 +1,221 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookStandIn is a stand-in webhook passing the requests it gets to requests.
+func webhookStandIn(t *testing.T) (*httptest.Server, chan string) {
+	requests := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests <- string(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestWebhookExporter(t *testing.T) {
+	srv, requests := webhookStandIn(t)
+	x, err := NewWebhookExporter(WebhookOptions{
+		URLs:     []string{srv.URL},
+		Template: `{{range .Alerts}}{{.Level}} {{.Logger}} {{.Message}} x{{.Count}} {{.Fields.code}}{{"\n"}}{{end}}`,
+		Batch:    BatchOptions{FlushInterval: time.Hour},
+	})
+	require.NoError(t, err)
+	defer x.Close()
+	remove, err := AddSink(Sink{Writer: x, MinLevel: ErrorLevel})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		Named("db").WithFields(Fields{"code": i}).Errorf("connection refused")
+	}
+	Named("api").WithFields(Fields{"code": 9}).Errorf("connection refused")
+	Named("db").Warnf("slow query")
+	remove()
+	require.NoError(t, x.Flush(context.Background()))
+
+	assert.Equal(t, "error db connection refused x3 0\nerror api connection refused x1 9\n", <-requests)
+}
+
+func TestWebhookExporter_defaultTemplate(t *testing.T) {
+	srv, requests := webhookStandIn(t)
+	x, err := NewWebhookExporter(WebhookOptions{URLs: []string{srv.URL}, Batch: BatchOptions{FlushInterval: time.Hour}})
+	require.NoError(t, err)
+	defer x.Close()
+	t0 := time.Date(2023, 10, 18, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, x.WriteEntry(&Entry{Time: t0, Level: ErrorLevel, Message: "disk full"}, []byte("disk \"full\"\n")))
+	require.NoError(t, x.WriteEntry(&Entry{Time: t0.Add(time.Second), Level: ErrorLevel, Message: "disk full"}, []byte("disk \"full\"\n")))
+	_, err = x.Write([]byte("quota exceeded\n"))
+	require.NoError(t, err)
+	require.NoError(t, x.Flush(context.Background()))
+
+	var payload map[string]string
+	require.NoError(t, json.Unmarshal([]byte(<-requests), &payload))
+	assert.Equal(t, map[string]string{"text": "disk \"full\" (repeated 2 times)\nquota exceeded"}, payload)
+}
+
+func TestWebhookExporter_minInterval(t *testing.T) {
+	srv1, requests1 := webhookStandIn(t)
+	srv2, requests2 := webhookStandIn(t)
+	x, err := NewWebhookExporter(WebhookOptions{
+		URLs:        []string{srv1.URL, srv2.URL},
+		MinInterval: 200 * time.Millisecond,
+		Batch:       BatchOptions{FlushInterval: 10 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	defer x.Close()
+
+	_, err = x.Write([]byte("first\n"))
+	require.NoError(t, err)
+	for _, requests := range []chan string{requests1, requests2} {
+		assert.Equal(t, `{"text": "first"}`, <-requests)
+	}
+	start := time.Now()
+	_, err = x.Write([]byte("second\n"))
+	require.NoError(t, err)
+	for _, requests := range []chan string{requests1, requests2} {
+		assert.Equal(t, `{"text": "second"}`, <-requests)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestWebhookExporter_minIntervalFatal(t *testing.T) {
+	srv, requests := webhookStandIn(t)
+	x, err := NewWebhookExporter(WebhookOptions{
+		URLs:        []string{srv.URL},
+		MinInterval: time.Hour,
+		Batch:       BatchOptions{FlushInterval: 10 * time.Millisecond, MaxEntries: 2},
+	})
+	require.NoError(t, err)
+	defer x.Close()
+
+	_, err = x.Write([]byte("burst 0\n"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"text": "burst 0"}`, <-requests)
+	// the burst waits for MinInterval, however full or old its batches
+	for i := 1; i <= 3; i++ {
+		_, err = x.Write([]byte(fmt.Sprintf("burst %d\n", i)))
+		require.NoError(t, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, requests)
+
+	start := time.Now()
+	require.NoError(t, x.WriteEntry(&Entry{Time: time.Now(), Level: FatalLevel, Message: "giving up"}, []byte("giving up\n")))
+	assert.Less(t, time.Since(start), time.Second)
+	// in batches of MaxEntries
+	assert.Equal(t, `{"text": "burst 1\nburst 2"}`, <-requests)
+	assert.Equal(t, `{"text": "burst 3\ngiving up"}`, <-requests)
+}
+
+func TestWebhookExporter_fatal(t *testing.T) {
+	srv1, requests1 := webhookStandIn(t)
+	srv2, requests2 := webhookStandIn(t)
+	x, err := NewWebhookExporter(WebhookOptions{URLs: []string{srv1.URL, srv2.URL}, Batch: BatchOptions{FlushInterval: time.Hour}})
+	require.NoError(t, err)
+	defer x.Close()
+
+	require.NoError(t, x.WriteEntry(&Entry{Time: time.Now(), Level: ErrorLevel, Message: "failing"}, []byte("failing\n")))
+	require.NoError(t, x.WriteEntry(&Entry{Time: time.Now(), Level: FatalLevel, Message: "giving up"}, []byte("giving up\n")))
+	for _, requests := range []chan string{requests1, requests2} {
+		select {
+		case r := <-requests:
+			assert.Equal(t, `{"text": "failing\ngiving up"}`, r)
+		default:
+			t.Fatal("fatal entry not posted before WriteEntry returned")
+		}
+	}
+}
+
+func TestNewWebhookExporter_invalid(t *testing.T) {
+	_, err := NewWebhookExporter(WebhookOptions{})
+	assert.EqualError(t, err, "missing webhook URL")
+	_, err = NewWebhookExporter(WebhookOptions{URLs: []string{"http://localhost"}, Template: "{{.Text"})
+	assert.ErrorContains(t, err, "parsing webhook template: ")
+	_, err = NewWebhookExporter(WebhookOptions{URLs: []string{"http://localhost", "http://localhost/%zz-token"}})
+	assert.EqualError(t, err, "invalid webhook URL 2")
+}
+
+func TestWebhookExporter_hidesURL(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	x, err := NewWebhookExporter(WebhookOptions{URLs: []string{srv.URL + "/services/secret-token"}})
+	require.NoError(t, err)
+	defer x.Close()
+	d := x.destinations[0]
+	assert.Equal(t, "webhook 1 ("+srv.Listener.Addr().String()+")", d.name)
+	assert.Equal(t, d.name, d.batcher.name)
+
+	err = x.send(context.Background(), d, []batchItem{{entry: &Entry{Level: ErrorLevel}, p: []byte("failing\n")}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), d.name)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
+
+func TestWebhookPayload_limits(t *testing.T) {
+	batch := func(lines ...string) []batchItem {
+		var items []batchItem
+		for _, line := range lines {
+			items = append(items, batchItem{entry: &Entry{Level: ErrorLevel, Message: line}, p: []byte(line + "\n")})
+		}
+		return items
+	}
+	a20 := strings.Repeat("a", 20)
+	testCases := []struct {
+		batch     []batchItem
+		maxAlerts int
+		maxText   int
+		want      string
+		alerts    int
+	}{
+		{batch("a", "b", "c"), 50, 100, "a\nb\nc", 3},
+		{batch("a", "b", "c"), 2, 100, "a\nb\n... and 1 more", 2},
+		{batch("a", "b", "a"), 1, 100, "a (repeated 2 times)\n... and 1 more", 1},
+		{batch("aaaa", "bbbb", "cccc"), 50, 14, "aaaa\nbbbb\ncccc", 3},
+		// room is kept for the last line
+		{batch(a20, a20+"b", a20+"c"), 50, 50, a20 + "\n... and 2 more", 1},
+		{batch(a20, a20+"b", a20+"c"), 50, 60, a20 + "\n" + a20 + "b\n... and 1 more", 2},
+		// a first alert too long is cut at a rune boundary
+		{batch("ééééé"), 50, 5, "éé", 1},
+		{batch(strings.Repeat("é", 20), "b"), 50, 25, "ééééé\n... and 1 more", 1},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			payload := webhookPayload(tc.batch, tc.maxAlerts, tc.maxText)
+			assert.Equal(t, tc.want, payload.Text)
+			assert.Len(t, payload.Alerts, tc.alerts)
+			assert.LessOrEqual(t, len(payload.Text), tc.maxText)
+		})
+	}
+}
+
+func TestWebhookExporter_omitted(t *testing.T) {
+	srv, requests := webhookStandIn(t)
+	x, err := NewWebhookExporter(WebhookOptions{
+		URLs:      []string{srv.URL},
+		Template:  `{{len .Alerts}} {{.Omitted}}`,
+		MaxAlerts: 2,
+		Batch:     BatchOptions{FlushInterval: time.Hour},
+	})
+	require.NoError(t, err)
+	defer x.Close()
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		_, err = x.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, x.Flush(context.Background()))
+	assert.Equal(t, "2 2", <-requests)
+}